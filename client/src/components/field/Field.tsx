@@ -10,12 +10,15 @@ import { gameState, setGameState } from "@/store/game";
 import { state } from "@/store";
 import { useWS } from "@/components/WSProvider";
 import type {
+  EnteredViewPayload,
   EventData,
   GameStatePayload,
+  LeftViewPayload,
   JoinGamePayload,
   MoveEventPayload,
   Playmate,
   Position,
+  SnapshotPayload,
   Step,
 } from "@/types";
 import {
@@ -27,9 +30,6 @@ import { EventName } from "@/types";
 import { RenderService, type Mesh } from "@/services/renderService";
 import { useKeyboard } from "@/composables/useKeypress";
 
-const fieldWidth = 10;
-const fieldHeight = 10;
-
 const [gameField] = createSignal({
   x: 0,
   y: 0,
@@ -37,14 +37,17 @@ const [gameField] = createSignal({
   height: 100,
 });
 
-const cellSide = 10;
-
 const Field: Component<{ players: Array<Position> }> = () => {
   const selfClientId = createMemo(() => state.clientId);
   const wsConn = useWS();
   let scene: RenderService;
 
   const [rotation, rotate] = createSignal<Rotation>(0);
+  // the field is sized by the map of the game, the minimap scales to fit it
+  const [fieldSize, setFieldSize] = createSignal({ width: 10, height: 10 });
+  const cellSide = createMemo(
+    () => gameField().width / Math.max(fieldSize().width, fieldSize().height)
+  );
 
   wsConn?.init();
   wsConn?.addEventListener("message", (e) => {
@@ -67,11 +70,60 @@ const Field: Component<{ players: Array<Position> }> = () => {
       });
     }
 
+    if (data.eventName === EventName.snapshot) {
+      const { players } = data.eventPayload as SnapshotPayload;
+      players.forEach(({ user, position }) => {
+        const current = gameState.locations[user.clientId];
+        if (
+          current?.position &&
+          current.position.x === position.x &&
+          current.position.y === position.y
+        ) {
+          return;
+        }
+        setGameState("locations", (locations) => ({
+          ...locations,
+          [user.clientId]: { user, position },
+        }));
+        const mesh = scene.getMeshByName(user.clientId);
+        if (mesh) {
+          scene.moveMesh2D(mesh as Mesh, position);
+        }
+      });
+    }
+
+    if (data.eventName === EventName.enteredView) {
+      const { user, position } = data.eventPayload as EnteredViewPayload;
+      setGameState("locations", (locations) => ({
+        ...locations,
+        [user.clientId]: { user, position },
+      }));
+      const mesh = scene.getMeshByName(user.clientId);
+      if (mesh) {
+        scene.moveMesh2D(mesh as Mesh, position);
+      }
+    }
+
+    if (data.eventName === EventName.leftView) {
+      const { clientId } = data.eventPayload as LeftViewPayload;
+      // setting a key of the store to undefined deletes it
+      setGameState("locations", clientId, undefined!);
+      const mesh = scene.getMeshByName(clientId);
+      if (mesh) {
+        RenderService.removeMesh(mesh as Mesh);
+      }
+    }
+
     if (data.eventName === EventName.gameState) {
-      const { connected } = data.eventPayload as GameStatePayload;
+      const { connected, map } = data.eventPayload as GameStatePayload;
+      const size = { width: map.width, height: map.height };
+      setFieldSize(size);
+      scene.setFieldSize(size);
       const userLocations: typeof gameState.locations = {};
       connected.forEach(({ user, position }) => {
-        userLocations[user.clientId] = { user, position };
+        if (position) {
+          userLocations[user.clientId] = { user, position };
+        }
       });
       setGameState("locations", () => userLocations);
     }
@@ -87,10 +139,12 @@ const Field: Component<{ players: Array<Position> }> = () => {
           )
         ),
         ...Object.fromEntries(
-          joining.map(({ user, position }) => [
-            user.clientId,
-            { user, position },
-          ])
+          joining
+            .filter(({ position }) => position)
+            .map(({ user, position }) => [
+              user.clientId,
+              { user, position: position as Position },
+            ])
         ),
       }));
 
@@ -158,10 +212,7 @@ const Field: Component<{ players: Array<Position> }> = () => {
 
   let canvas!: HTMLCanvasElement;
   onMount(() => {
-    scene = new RenderService(canvas, {
-      width: fieldWidth,
-      height: fieldHeight,
-    });
+    scene = new RenderService(canvas, fieldSize());
     useKeyboard(handleMove, canvas);
   });
 
@@ -191,16 +242,16 @@ const Field: Component<{ players: Array<Position> }> = () => {
                   },
                 ]) => (
                   <rect
-                    x={x * cellSide}
-                    y={y * cellSide}
-                    width={cellSide}
-                    height={cellSide}
+                    x={x * cellSide()}
+                    y={y * cellSide()}
+                    width={cellSide()}
+                    height={cellSide()}
                     fill={clientId === selfClientId() ? "red" : "black"}
                     transform={
                       clientId === selfClientId()
                         ? `rotate(${rotation()} ${
-                            x * cellSide + cellSide / 2
-                          } ${y * cellSide + cellSide / 2})`
+                            x * cellSide() + cellSide() / 2
+                          } ${y * cellSide() + cellSide() / 2})`
                         : ""
                     }
                   />
@@ -1,5 +1,5 @@
 import { HttpService } from "@/services/httpService";
-import { setState } from "@/store";
+import { state, setState } from "@/store";
 
 const parseJwt = (token: string): Record<string, any> | null => {
   try {
@@ -19,10 +19,45 @@ export class AuthService {
     if (!response.ok) {
       throw new Error();
     }
-    const token = response.headers.get("Authorization")?.split(" ")[1];
+    await AuthService.storeTokens(response);
+  }
+
+  // access tokens are short lived, the refresh token trades for a new pair
+  static async refresh() {
+    const refreshToken = state.refreshToken;
+    if (refreshToken === null) {
+      return false;
+    }
+    const response = await HttpService.post("api/refresh", { refreshToken });
+    if (!response.ok) {
+      setState("token", () => null);
+      setState("refreshToken", () => null);
+      return false;
+    }
+    await AuthService.storeTokens(response);
+    return true;
+  }
+
+  static async logOut() {
+    const token = state.token;
+    if (token !== null) {
+      await HttpService.post("api/logout", undefined, {
+        headers: { Authorization: "Bearer " + token },
+      });
+    }
+    setState("token", () => null);
+    setState("refreshToken", () => null);
+    setState("user", () => null);
+  }
+
+  protected static async storeTokens(response: Response) {
+    const { token, refreshToken } = await response.json();
     if (token) {
       setState("token", () => token);
       setState("user", parseJwt(token));
     }
+    if (refreshToken) {
+      setState("refreshToken", () => refreshToken);
+    }
   }
 }
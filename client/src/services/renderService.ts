@@ -64,6 +64,11 @@ export class RenderService {
     return this.scene.getMeshByName(meshName);
   }
 
+  // the field size is only known once the map of the game arrives
+  setFieldSize(fieldSize: { width: number; height: number }) {
+    this.fieldSize = fieldSize;
+  }
+
   moveMesh2D(mesh: Mesh, position: { x: number; y: number }) {
     const { x, y } = position;
     const fieldCoordinates = {
@@ -1,4 +1,5 @@
 import { BASE_URL } from "@/constants";
+import { AuthService } from "@/services/authService";
 import { state, setState } from "@/store";
 import type { Nullable } from "@/types";
 
@@ -14,14 +15,16 @@ export class WSService {
     if (wsConnection !== null) {
       return false;
     }
+    // the access token may have expired since the login
+    await AuthService.refresh();
     const token = state.token;
     if (token === null) {
       return false;
     }
     const url = new URL(BASE_URL + "ws");
-    url.searchParams.append("token", token);
     url.protocol = "ws";
-    this.webSocket = new WebSocket(url.toString());
+    // the token goes as a subprotocol to keep it out of the url
+    this.webSocket = new WebSocket(url.toString(), ["access_token", token]);
     const initializationPromise = new Promise(resolve => {
       (this.webSocket as WebSocket).onopen = () => {
         setState("wsConnReady", () => true);
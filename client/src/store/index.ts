@@ -4,6 +4,7 @@ import { WSService } from "@/services/socketService";
 
 type State = {
   token: string | null;
+  refreshToken: string | null;
   clientId: Nullable<string>;
   user: Nullable<Pick<Playmate, "userId" | "userName">>;
   playmates: Array<Playmate>;
@@ -13,6 +14,7 @@ type State = {
 
 export const [state, setState] = createStore<State>({
   token: null,
+  refreshToken: null,
   clientId: null,
   user: null,
   playmates: [],
@@ -31,20 +31,31 @@ export enum EventName {
   move = "move",
   gameState = "gameState",
   joinGame = "joinGame",
+  snapshot = "snapshot",
+  enteredView = "enteredView",
+  leftView = "leftView",
 }
 
 export type JoinDisconnectPayload = { clientId: string; users: Playmate[] };
 export type MessageEventPayload = { userID: number; message: string };
+export type GameMap = {
+  name: string;
+  layout: string[];
+  width: number;
+  height: number;
+};
+// players out of sight come without a position
 export type GameStatePayload = {
   connected: Array<{
     user: Playmate;
-    position: Position;
+    position?: Position;
   }>;
+  map: GameMap;
 };
 export type JoinGamePayload = {
   joining: Array<{
     user: Playmate;
-    position: Position;
+    position?: Position;
   }>;
   disconnecting: Array<{
     user: Playmate;
@@ -57,6 +68,20 @@ export type MoveEventPayload = {
   x: Position["x"];
   y: Position["y"];
 };
+export type SnapshotPayload = {
+  tick: number;
+  players: Array<{
+    user: Playmate;
+    position: Position;
+    health: number;
+  }>;
+};
+export type EnteredViewPayload = {
+  user: Playmate;
+  position: Position;
+  health: number;
+};
+export type LeftViewPayload = { clientId: string };
 export type EventData = {
   eventName: EventName;
   eventPayload:
@@ -64,5 +89,8 @@ export type EventData = {
     | MessageEventPayload
     | MoveEventPayload
     | GameStatePayload
-    | JoinGamePayload;
+    | JoinGamePayload
+    | SnapshotPayload
+    | EnteredViewPayload
+    | LeftViewPayload;
 };
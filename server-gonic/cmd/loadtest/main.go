@@ -0,0 +1,393 @@
+// Command loadtest plays the server with many headless clients at once. Every
+// client registers and logs in through the REST API, opens a websocket, joins
+// a game and keeps moving and shooting at the given rate. Every input carries
+// a sequence number the server echoes in its answer. The latency of every
+// shot, from sending it until the server answers with a "shot" or an
+// "actionRejected" event, is reported together with dropped connections and
+// errors once the test is over. Inputs the server dropped or rejected and
+// shots left without an answer count as errors.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// shotTimeout is how long a shot may wait for its answer before it's given up
+const shotTimeout = 5 * time.Second
+
+// inputQueueFull is the reason the server drops inputs for when it can't keep up
+const inputQueueFull = "inputQueueFull"
+
+type config struct {
+	server   string
+	users    int
+	rate     float64
+	duration time.Duration
+	rampUp   time.Duration
+	roomId   string
+	prefix   string
+	password string
+}
+
+type socketEvent struct {
+	EventName    string                 `json:"eventName"`
+	EventPayload map[string]interface{} `json:"eventPayload"`
+}
+
+// stats collects the results of all clients
+type stats struct {
+	mutex          sync.Mutex
+	latencies      []time.Duration
+	connected      int
+	dropped        int
+	errors         map[string]int
+	rejected       map[string]int
+	eventsSent     int
+	eventsReceived int
+}
+
+func (s *stats) addError(kind string, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.errors[kind]++
+	if s.errors[kind] <= 3 {
+		log.Printf("%s: %v", kind, err)
+	}
+}
+
+func main() {
+	cfg := config{}
+	flag.StringVar(&cfg.server, "server", "http://localhost:3003", "base url of the server")
+	flag.IntVar(&cfg.users, "users", 10, "number of simulated players")
+	flag.Float64Var(&cfg.rate, "rate", 5, "inputs per second sent by every player")
+	flag.DurationVar(&cfg.duration, "duration", time.Minute, "how long the players keep sending inputs")
+	flag.DurationVar(&cfg.rampUp, "ramp-up", 10*time.Second, "time over which the players connect")
+	flag.StringVar(&cfg.roomId, "room", "", "room to join, the default room when empty")
+	flag.StringVar(&cfg.prefix, "prefix", fmt.Sprintf("loadtest-%d", time.Now().Unix()), "prefix of the user names")
+	flag.StringVar(&cfg.password, "password", "loadtest-password", "password of the users")
+	flag.Parse()
+
+	if cfg.users <= 0 || cfg.rate <= 0 {
+		fmt.Fprintln(os.Stderr, "users and rate must be positive")
+		os.Exit(2)
+	}
+
+	results := &stats{errors: map[string]int{}, rejected: map[string]int{}}
+	deadline := time.Now().Add(cfg.rampUp + cfg.duration)
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.users; i++ {
+		wg.Add(1)
+		go func(number int) {
+			defer wg.Done()
+			runPlayer(cfg, number, deadline, results)
+		}(i)
+		time.Sleep(cfg.rampUp / time.Duration(cfg.users))
+	}
+	wg.Wait()
+
+	report(cfg, results)
+}
+
+// runPlayer logs a single player in and plays until the deadline
+func runPlayer(cfg config, number int, deadline time.Time, results *stats) {
+	username := fmt.Sprintf("%s-%d", cfg.prefix, number)
+	token, err := login(cfg, username)
+	if err != nil {
+		results.addError("login", err)
+		return
+	}
+
+	ticket, err := wsTicket(cfg, token)
+	if err != nil {
+		results.addError("ticket", err)
+		return
+	}
+
+	wsUrl, err := url.Parse(cfg.server)
+	if err != nil {
+		results.addError("url", err)
+		return
+	}
+	wsUrl.Scheme = strings.Replace(wsUrl.Scheme, "http", "ws", 1)
+	wsUrl.Path = "/ws"
+	wsUrl.RawQuery = url.Values{"ticket": {ticket}}.Encode()
+
+	connection, _, err := websocket.DefaultDialer.Dial(wsUrl.String(), nil)
+	if err != nil {
+		results.addError("dial", err)
+		return
+	}
+	defer connection.Close()
+	results.mutex.Lock()
+	results.connected++
+	results.mutex.Unlock()
+
+	// shots waiting for their answer by sequence number
+	var pendingMutex sync.Mutex
+	pendingShots := map[int64]time.Time{}
+	answerShot := func(event socketEvent, measured bool) {
+		seq, ok := event.EventPayload["seq"].(float64)
+		if !ok {
+			return
+		}
+		pendingMutex.Lock()
+		sentAt, ok := pendingShots[int64(seq)]
+		delete(pendingShots, int64(seq))
+		pendingMutex.Unlock()
+		if !ok || !measured {
+			return
+		}
+		results.mutex.Lock()
+		results.latencies = append(results.latencies, time.Since(sentAt))
+		results.mutex.Unlock()
+	}
+	giveUpShots := func(sentBefore time.Time) {
+		pendingMutex.Lock()
+		defer pendingMutex.Unlock()
+		for seq, sentAt := range pendingShots {
+			if sentAt.Before(sentBefore) {
+				delete(pendingShots, seq)
+				results.addError("unanswered shot", fmt.Errorf("shot %d got no answer within %v", seq, shotTimeout))
+			}
+		}
+	}
+
+	readerDone := make(chan struct{})
+	clientId := ""
+	go func() {
+		defer close(readerDone)
+		for {
+			var event socketEvent
+			if err := connection.ReadJSON(&event); err != nil {
+				if time.Now().Before(deadline) {
+					results.mutex.Lock()
+					results.dropped++
+					results.mutex.Unlock()
+					results.addError("read", err)
+				}
+				return
+			}
+			results.mutex.Lock()
+			results.eventsReceived++
+			results.mutex.Unlock()
+
+			switch event.EventName {
+			case "join":
+				if clientId == "" {
+					clientId = ownClientId(event, username)
+				}
+			case "shot":
+				if shooter, _ := event.EventPayload["shooter"].(string); shooter != "" && shooter == clientId {
+					answerShot(event, true)
+				}
+			case "actionRejected":
+				action, _ := event.EventPayload["action"].(string)
+				reason, _ := event.EventPayload["reason"].(string)
+				results.mutex.Lock()
+				results.rejected[action+": "+reason]++
+				results.mutex.Unlock()
+				// a dropped shot never reached the game, it tells nothing about the latency
+				if reason == inputQueueFull {
+					results.addError("dropped input", fmt.Errorf("%s dropped by the server", action))
+				} else {
+					results.addError("rejected input", fmt.Errorf("%s rejected: %s", action, reason))
+				}
+				if action == "shoot" {
+					answerShot(event, reason != inputQueueFull)
+				}
+			}
+		}
+	}()
+
+	send := func(event socketEvent) bool {
+		connection.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if err := connection.WriteJSON(event); err != nil {
+			results.addError("write", err)
+			return false
+		}
+		results.mutex.Lock()
+		results.eventsSent++
+		results.mutex.Unlock()
+		return true
+	}
+
+	join := socketEvent{EventName: "joinGame", EventPayload: map[string]interface{}{}}
+	if cfg.roomId != "" {
+		join.EventPayload["roomId"] = cfg.roomId
+	}
+	if !send(join) {
+		return
+	}
+
+	var seq int64
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.rate))
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if now.After(deadline) {
+			break
+		}
+		select {
+		case <-readerDone:
+			return
+		default:
+		}
+
+		giveUpShots(now.Add(-shotTimeout))
+
+		seq++
+		payload := randomDirection()
+		payload["seq"] = seq
+		event := socketEvent{EventName: "move", EventPayload: payload}
+		if rand.Intn(2) == 0 {
+			event.EventName = "shoot"
+			pendingMutex.Lock()
+			pendingShots[seq] = time.Now()
+			pendingMutex.Unlock()
+		}
+		if !send(event) {
+			return
+		}
+	}
+
+	connection.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	select {
+	case <-readerDone:
+	case <-time.After(time.Second):
+	}
+}
+
+// login registers the user, which fails harmlessly when it already exists,
+// and returns the token the server hands out on login
+func login(cfg config, username string) (string, error) {
+	credentials, _ := json.Marshal(map[string]string{"username": username, "password": cfg.password})
+
+	response, err := http.Post(cfg.server+"/api/register", "application/json", bytes.NewReader(credentials))
+	if err != nil {
+		return "", err
+	}
+	response.Body.Close()
+
+	response, err = http.Post(cfg.server+"/api/login", "application/json", bytes.NewReader(credentials))
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("login of %s answered with %s", username, response.Status)
+	}
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token == "" {
+		return "", errors.New("login answered without a token")
+	}
+	return body.Token, nil
+}
+
+// wsTicket trades the token for the single-use ticket the websocket is opened with
+func wsTicket(cfg config, token string) (string, error) {
+	request, err := http.NewRequest(http.MethodPost, cfg.server+"/api/ws-ticket", nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ticket request answered with %s", response.Status)
+	}
+	var body struct {
+		Ticket string `json:"ticket"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Ticket == "" {
+		return "", errors.New("ticket request answered without a ticket")
+	}
+	return body.Ticket, nil
+}
+
+// ownClientId finds the connection's client id in the "join" event
+// the server broadcasts once the connection is registered
+func ownClientId(event socketEvent, username string) string {
+	clientId, _ := event.EventPayload["clientId"].(string)
+	users, _ := event.EventPayload["users"].([]interface{})
+	for _, user := range users {
+		fields, _ := user.(map[string]interface{})
+		if fields["clientId"] == clientId && fields["userName"] == username {
+			return clientId
+		}
+	}
+	return ""
+}
+
+func randomDirection() map[string]interface{} {
+	for {
+		x := rand.Intn(3) - 1
+		y := rand.Intn(3) - 1
+		if x != 0 || y != 0 {
+			return map[string]interface{}{"x": x, "y": y}
+		}
+	}
+}
+
+func report(cfg config, results *stats) {
+	results.mutex.Lock()
+	defer results.mutex.Unlock()
+
+	sort.Slice(results.latencies, func(i, j int) bool {
+		return results.latencies[i] < results.latencies[j]
+	})
+	fmt.Printf("players:            %d\n", cfg.users)
+	fmt.Printf("connected:          %d\n", results.connected)
+	fmt.Printf("dropped:            %d\n", results.dropped)
+	fmt.Printf("events sent:        %d\n", results.eventsSent)
+	fmt.Printf("events received:    %d\n", results.eventsReceived)
+	fmt.Printf("shots answered:     %d\n", len(results.latencies))
+	fmt.Printf("latency p50:        %v\n", percentile(results.latencies, 0.50))
+	fmt.Printf("latency p99:        %v\n", percentile(results.latencies, 0.99))
+
+	if len(results.errors) > 0 {
+		fmt.Println("errors:")
+		for kind, count := range results.errors {
+			fmt.Printf("  %-16s  %d\n", kind, count)
+		}
+	}
+	if len(results.rejected) > 0 {
+		fmt.Println("rejected actions:")
+		for reason, count := range results.rejected {
+			fmt.Printf("  %-24s  %d\n", reason, count)
+		}
+	}
+}
+
+// percentile expects the latencies to be sorted
+func percentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	index := int(float64(len(latencies)-1) * p)
+	return latencies[index]
+}
@@ -1,10 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"shooter/models"
+	"shooter/socket"
+	jwt_token "shooter/utils/jwt"
+	"shooter/utils/revoked_tokens"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 )
 
 type RegisterInput struct {
@@ -17,6 +22,10 @@ type LoginInput struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type RefreshInput struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
 func Register(c *gin.Context) {
 
 	var input RegisterInput
@@ -56,14 +65,72 @@ func Login(c *gin.Context) {
 	u.Username = input.Username
 	u.Password = input.Password
 
-	token, err := models.LoginCheck(u.Username, u.Password)
+	tokens, err := models.LoginCheck(u.Username, u.Password)
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "username or password is incorrect."})
 		return
 	}
 	c.Header("Access-Control-Expose-Headers", "*")
-	c.Header("Authorization", "Bearer "+token)
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.Header("Authorization", "Bearer "+tokens.AccessToken)
+	c.JSON(http.StatusOK, tokens)
+
+}
+
+// Refresh trades a refresh token for a new pair. Presenting a refresh token
+// a second time logs out every device of the login it came from.
+func Refresh(c *gin.Context, db *redis.Client, hub *socket.Hub) {
+
+	var input RefreshInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, family, err := models.RotateRefreshToken(input.RefreshToken)
+	if errors.Is(err, models.ErrRefreshTokenReused) {
+		if err := revoked_tokens.RevokeFamily(db, family); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		hub.RevokeFamily(family)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, models.ErrInvalidRefreshToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Access-Control-Expose-Headers", "*")
+	c.Header("Authorization", "Bearer "+tokens.AccessToken)
+	c.JSON(http.StatusOK, tokens)
+
+}
+
+// Logout revokes the refresh and access tokens of the login
+// and closes the websockets opened with them
+func Logout(c *gin.Context, db *redis.Client, hub *socket.Hub) {
+
+	userData, err := jwt_token.ExtractTokenData(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := models.RevokeTokenFamily(userData.Family); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := revoked_tokens.RevokeFamily(db, userData.Family); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	hub.RevokeFamily(userData.Family)
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
 
 }
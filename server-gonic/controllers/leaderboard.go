@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"net/http"
+	"shooter/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Leaderboard ranks the players by rating. The season query parameter, or
+// the since and until ones (RFC 3339), limit it to the ratings reached
+// within a season.
+func Leaderboard(c *gin.Context) {
+	limit, offset := pagination(c)
+
+	var since, until time.Time
+	var err error
+	if value := c.Query("since"); value != "" {
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since date"})
+			return
+		}
+	}
+	if value := c.Query("until"); value != "" {
+		if until, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid until date"})
+			return
+		}
+	}
+	if value := c.Query("season"); value != "" {
+		seasonId, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid season id"})
+			return
+		}
+		season, err := models.GetSeason(uint(seasonId))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		since = season.StartsAt
+		if season.ArchivedAt != nil {
+			until = season.EndsAt
+		}
+	}
+
+	leaderboard, err := models.GetLeaderboard(since, until, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"leaderboard": leaderboard})
+}
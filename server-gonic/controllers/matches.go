@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"net/http"
+	"shooter/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultPageSize = 20
+const maxPageSize = 100
+
+// pagination reads the page and limit query parameters
+func pagination(c *gin.Context) (limit int, offset int) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	return limit, (page - 1) * limit
+}
+
+func Matches(c *gin.Context) {
+	limit, offset := pagination(c)
+
+	matches, err := models.GetMatches(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"matches": matches})
+}
+
+func UserMatches(c *gin.Context) {
+	userId, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	limit, offset := pagination(c)
+
+	matches, err := models.GetUserMatches(uint(userId), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"matches": matches})
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"shooter/leaderboard"
+	"shooter/models"
+	"shooter/seasons"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Seasons(c *gin.Context) {
+	list, err := models.GetSeasons()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"seasons": list})
+}
+
+// SeasonStandings returns the archived standings of a season,
+// or the current ones while it's still running
+func SeasonStandings(c *gin.Context) {
+	season, ok := seasonFromParam(c)
+	if !ok {
+		return
+	}
+	limit, offset := pagination(c)
+
+	standings, err := models.GetSeasonStandings(season, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"season": season, "standings": standings})
+}
+
+// RolloverSeason archives the season and starts the next one,
+// repeating it for an archived season does nothing
+func RolloverSeason(c *gin.Context, leaderboards *leaderboard.Leaderboards) {
+	season, ok := seasonFromParam(c)
+	if !ok {
+		return
+	}
+
+	next, err := seasons.Rollover(leaderboards, season.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"current": next})
+}
+
+func seasonFromParam(c *gin.Context) (models.Season, bool) {
+	seasonId, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid season id"})
+		return models.Season{}, false
+	}
+	season, err := models.GetSeason(uint(seasonId))
+	if errors.Is(err, models.ErrSeasonNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return season, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return season, false
+	}
+	return season, true
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"shooter/leaderboard"
+	jwt_token "shooter/utils/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatLeaderboard returns the top of the live leaderboard of a stat,
+// the rating leaderboard is served by Leaderboard
+func StatLeaderboard(c *gin.Context, boards *leaderboard.Leaderboards) {
+	stat := c.Param("stat")
+	window := c.DefaultQuery("window", leaderboard.AllTime)
+	limit, _ := pagination(c)
+
+	top, err := boards.Top(stat, window, int64(limit))
+	if err != nil {
+		statLeaderboardError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stat": stat, "window": window, "top": top})
+}
+
+// StatLeaderboardAroundMe returns the players ranked around the logged in user
+// on the live leaderboard of a stat
+func StatLeaderboardAroundMe(c *gin.Context, boards *leaderboard.Leaderboards) {
+	userData, err := jwt_token.ExtractTokenData(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	stat := c.Param("stat")
+	window := c.DefaultQuery("window", leaderboard.AllTime)
+
+	around, err := boards.AroundUser(stat, window, userData.UserId)
+	if err != nil {
+		statLeaderboardError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stat": stat, "window": window, "around": around})
+}
+
+// RebuildStatLeaderboards refills the live leaderboards from the stored matches
+func RebuildStatLeaderboards(c *gin.Context, boards *leaderboard.Leaderboards) {
+	if err := boards.Rebuild(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "leaderboards rebuilt"})
+}
+
+func statLeaderboardError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, leaderboard.ErrUnknownStat),
+		errors.Is(err, leaderboard.ErrUnknownWindow),
+		errors.Is(err, leaderboard.ErrNoSeason):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"shooter/leaderboard"
+	jwt_token "shooter/utils/jwt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestStatLeaderboardAroundMe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("API_SECRET", "test-secret")
+	db := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { db.Close() })
+
+	// far more players than fit around a single one
+	boards := leaderboard.New(db)
+	results := []leaderboard.Result{}
+	for userId := uint(1); userId <= 3*leaderboard.Around; userId++ {
+		results = append(results, leaderboard.Result{UserID: userId, Username: "player", Kills: int(userId)})
+	}
+	if err := boards.RecordMatch(results); err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt_token.GenerateToken(1, "player", "family")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{name: "the logged in user", token: token, status: http.StatusOK},
+		{name: "without a token", token: "", status: http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			// the user in the query is never the one ranked
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/leaderboards/kills/around-me?userId=20", nil)
+			if test.token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			c.Params = gin.Params{{Key: "stat", Value: leaderboard.Kills}}
+
+			StatLeaderboardAroundMe(c, boards)
+
+			if recorder.Code != test.status {
+				t.Fatalf("status = %d, want %d", recorder.Code, test.status)
+			}
+			if test.status != http.StatusOK {
+				return
+			}
+			var body struct {
+				Around []leaderboard.Entry `json:"around"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			last := body.Around[len(body.Around)-1]
+			if last.UserID != 1 || last.Rank != int64(len(results)) {
+				t.Errorf("around ends with %+v, want user 1 ranked last", last)
+			}
+			if len(body.Around) != leaderboard.Around+1 {
+				t.Errorf("around = %d entries, want %d", len(body.Around), leaderboard.Around+1)
+			}
+		})
+	}
+}
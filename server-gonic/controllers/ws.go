@@ -5,31 +5,69 @@ import (
 	"net/http"
 	"shooter/socket"
 	jwt_token "shooter/utils/jwt"
+	"shooter/utils/revoked_tokens"
+	"shooter/utils/ws_ticket"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/websocket"
+	"golang.org/x/exp/slices"
 )
 
-func WS(c *gin.Context, hub *socket.Hub) {
+// AllowedOrigins are the origins browsers may open the websocket from, "*"
+// allows any. Without allowed origins only the server's own host is accepted.
+var AllowedOrigins = []string{}
+
+// checkOrigin lets through clients outside a browser, which send no origin
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || slices.Contains(AllowedOrigins, "*") {
+		return true
+	}
+	return slices.Contains(AllowedOrigins, origin)
+}
+
+func WS(c *gin.Context, hub *socket.Hub, db *redis.Client) {
+
+	// the user is checked before upgrading, so a refused client gets a plain HTTP answer
+	var userData jwt_token.TokenData
+	var err error
+	if ticket := c.Query("ticket"); ticket != "" {
+		userData, err = ws_ticket.Consume(db, ticket)
+	} else {
+		userData, err = jwt_token.ExtractTokenData(c)
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	revoked, err := revoked_tokens.IsRevoked(db, userData)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if revoked {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
 
 	var upgrader = websocket.Upgrader{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
+		// the token may come as a subprotocol, which has to be echoed
+		Subprotocols: []string{jwt_token.TokenProtocol},
+	}
+	if len(AllowedOrigins) > 0 {
+		upgrader.CheckOrigin = checkOrigin
 	}
 
 	// Upgrading the HTTP connection socket connection
-	upgrader.CheckOrigin = func(r *http.Request) bool { return true }
 	connection, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Println(err)
 		return
 	}
 
-	userData, err := jwt_token.ExtractTokenData(c)
-	if err != nil {
-		log.Println(err)
-		return
-	}
-	socket.CreateNewSocketUser(hub, connection, int(userData.UserId), userData.UserName)
+	socket.CreateNewSocketUser(hub, connection, int(userData.UserId), userData.UserName, userData.Family, c.Query("resume"))
 
 }
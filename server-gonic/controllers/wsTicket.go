@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"net/http"
+	jwt_token "shooter/utils/jwt"
+	"shooter/utils/ws_ticket"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// WSTicket hands the logged in user a short-lived ticket to open the websocket with
+func WSTicket(c *gin.Context, db *redis.Client) {
+	userData, err := jwt_token.ExtractTokenData(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	ticket, err := ws_ticket.Issue(db, userData)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ticket": ticket, "expiresIn": int(ws_ticket.TicketLifespan.Seconds())})
+}
@@ -0,0 +1,244 @@
+package game
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const botIdPrefix = "bot-"
+
+const DefaultBotDifficulty = "normal"
+
+// BotDifficulty tunes how well a bot plays.
+type BotDifficulty struct {
+	// Accuracy is the chance that a shot is aimed at the target rather than next to it.
+	Accuracy float64
+	// ReactionTime is the delay between spotting a target and the first shot.
+	ReactionTime time.Duration
+	// MoveInterval is the time between two steps.
+	MoveInterval time.Duration
+}
+
+var BotDifficulties = map[string]BotDifficulty{
+	"easy":   {Accuracy: 0.4, ReactionTime: 900 * time.Millisecond, MoveInterval: 450 * time.Millisecond},
+	"normal": {Accuracy: 0.7, ReactionTime: 500 * time.Millisecond, MoveInterval: 300 * time.Millisecond},
+	"hard":   {Accuracy: 0.9, ReactionTime: 200 * time.Millisecond, MoveInterval: 180 * time.Millisecond},
+}
+
+var ErrUnknownBotDifficulty = RejectedAction{Reason: "unknownBotDifficulty"}
+
+// Bot is what a player controlled by the server remembers between ticks.
+type Bot struct {
+	Name        string    `json:"name"`
+	Difficulty  string    `json:"difficulty"`
+	Target      string    `json:"target"`
+	TargetSince time.Time `json:"targetSince"`
+	Goal        *Position `json:"goal"`
+	LastMoveAt  time.Time `json:"lastMoveAt"`
+}
+
+// BotAction is an input a bot sends, it's applied like the input of a human.
+type BotAction struct {
+	Name      string
+	Direction Position
+}
+
+func HasBotDifficulty(name string) bool {
+	_, ok := BotDifficulties[name]
+	return ok
+}
+
+func IsBot(playerId string) bool {
+	return len(playerId) > len(botIdPrefix) && playerId[:len(botIdPrefix)] == botIdPrefix
+}
+
+// Humans counts the players not controlled by the server
+func (state *GameState) Humans() int {
+	humans := 0
+	for playerId := range state.Players {
+		if !IsBot(playerId) {
+			humans++
+		}
+	}
+	return humans
+}
+
+// FillWithBots adds bots until the game has the given number of players and
+// takes them out again as humans join. A game without humans has no bots.
+func (state *GameState) FillWithBots(players int) (added []string, removed []string) {
+	added = []string{}
+	removed = []string{}
+	if state.Humans() == 0 {
+		players = 0
+	}
+
+	bots := []string{}
+	for playerId := range state.Players {
+		if IsBot(playerId) {
+			bots = append(bots, playerId)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(bots)))
+	for _, botId := range bots {
+		if len(state.Players) <= players {
+			break
+		}
+		state.RemovePlayer(botId)
+		removed = append(removed, botId)
+	}
+	for number := 1; len(state.Players) < players; number++ {
+		botId := botIdPrefix + strconv.Itoa(number)
+		if _, ok := state.Players[botId]; ok {
+			continue
+		}
+		if err := state.AddPlayer(botId, []Weapon{}, ""); err != nil {
+			break
+		}
+		state.Players[botId].Bot = &Bot{
+			Name:       "Bot " + strconv.Itoa(number),
+			Difficulty: state.BotDifficulty,
+		}
+		added = append(added, botId)
+	}
+	return added, removed
+}
+
+// BotActions decides what the bot does on this tick. A bot shoots the
+// nearest enemy it sees once it is lined up with it, and otherwise walks
+// towards it or, without anyone in sight, towards a random cell.
+func (state *GameState) BotActions(botId string, now time.Time) []BotAction {
+	player, ok := state.Players[botId]
+	location, isInGame := state.Locations[botId]
+	if !ok || player.Bot == nil || !isInGame {
+		return []BotAction{}
+	}
+	bot := player.Bot
+	difficulty, ok := BotDifficulties[bot.Difficulty]
+	if !ok {
+		difficulty = BotDifficulties[DefaultBotDifficulty]
+	}
+	if now.Before(player.ReloadingUntil) {
+		return []BotAction{}
+	}
+	slot := player.activeSlot()
+	if slot.Magazine == 0 {
+		if slot.Reserve == 0 {
+			return []BotAction{}
+		}
+		return []BotAction{{Name: "reload"}}
+	}
+
+	target := state.nearestEnemy(botId)
+	if target != bot.Target {
+		bot.Target = target
+		bot.TargetSince = now
+	}
+	if target != "" {
+		enemy := *state.Locations[target]
+		if direction, ok := state.lineOfFire(*location, enemy, slot.Weapon.Range); ok {
+			if now.Sub(bot.TargetSince) < difficulty.ReactionTime ||
+				now.Before(player.LastShotAt.Add(slot.Weapon.Cooldown)) {
+				return []BotAction{}
+			}
+			if rand.Float64() > difficulty.Accuracy {
+				direction = missedDirection(direction)
+			}
+			return []BotAction{{Name: "shoot", Direction: direction}}
+		}
+		bot.Goal = &enemy
+	}
+
+	if now.Sub(bot.LastMoveAt) < difficulty.MoveInterval {
+		return []BotAction{}
+	}
+	field := state.CurrentMap()
+	if bot.Goal == nil || *bot.Goal == *location {
+		bot.Goal = state.randomGoal()
+	}
+	if bot.Goal == nil {
+		return []BotAction{}
+	}
+	step, ok := field.nextStep(*location, *bot.Goal)
+	if !ok {
+		bot.Goal = nil
+		return []BotAction{}
+	}
+	bot.LastMoveAt = now
+	return []BotAction{{Name: "move", Direction: step}}
+}
+
+// nearestEnemy returns the closest player the bot can see and is allowed to hurt
+func (state *GameState) nearestEnemy(botId string) string {
+	location := state.Locations[botId]
+	nearest := ""
+	nearestDistance := 0
+	for _, playerId := range state.VisiblePlayers(botId) {
+		if playerId == botId || state.areTeammates(botId, playerId) {
+			continue
+		}
+		enemy := state.Locations[playerId]
+		distance := max(abs(enemy.X-location.X), abs(enemy.Y-location.Y))
+		if nearest == "" || distance < nearestDistance {
+			nearest = playerId
+			nearestDistance = distance
+		}
+	}
+	return nearest
+}
+
+// lineOfFire returns the direction of a shot from one cell to another,
+// if the target is on the same row, column or diagonal within range
+// and nothing stands in between
+func (state *GameState) lineOfFire(from Position, to Position, weaponRange int) (Position, bool) {
+	dx := to.X - from.X
+	dy := to.Y - from.Y
+	if dx != 0 && dy != 0 && abs(dx) != abs(dy) {
+		return Position{}, false
+	}
+	distance := max(abs(dx), abs(dy))
+	if distance == 0 || distance > weaponRange {
+		return Position{}, false
+	}
+
+	direction := Position{X: sign(dx), Y: sign(dy)}
+	field := state.CurrentMap()
+	for step := 1; step < distance; step++ {
+		x := from.X + step*direction.X
+		y := from.Y + step*direction.Y
+		if field.blocksShots(x, y) || state.playerAt(x, y) != "" {
+			return Position{}, false
+		}
+	}
+	return direction, true
+}
+
+// missedDirection turns the aim one direction to either side
+func missedDirection(aim Position) Position {
+	for i, direction := range directions {
+		if direction == aim {
+			return directions[(i+len(directions)+rand.Intn(2)*2-1)%len(directions)]
+		}
+	}
+	return aim
+}
+
+// randomGoal picks a cell a bot can walk to
+func (state *GameState) randomGoal() *Position {
+	field := state.CurrentMap()
+	for attempt := 0; attempt < 100; attempt++ {
+		goal := Position{X: rand.Intn(field.width), Y: rand.Intn(field.height)}
+		if !field.blocksMovement(goal.X, goal.Y) {
+			return &goal
+		}
+	}
+	return nil
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,97 @@
+package game
+
+import "time"
+
+// FlagReturnDelay is how long a dropped flag lies on the field before it returns to its base.
+var FlagReturnDelay = 30 * time.Second
+
+// Flag is the flag of a team. It's either at its base, carried by
+// an enemy or dropped where its carrier was killed.
+type Flag struct {
+	Base      Position  `json:"base"`
+	Position  Position  `json:"position"`
+	Carrier   string    `json:"carrier"`
+	DroppedAt time.Time `json:"droppedAt"`
+}
+
+// FlagView is a flag as a player sees it. Where a flag away from its base is
+// and who carries it is only known while the player sees its cell.
+type FlagView struct {
+	Base     Position  `json:"base"`
+	Home     bool      `json:"home"`
+	Position *Position `json:"position,omitempty"`
+	Carrier  string    `json:"carrier,omitempty"`
+}
+
+func (flag *Flag) isHome() bool {
+	return flag.Carrier == "" && flag.Position == flag.Base
+}
+
+// CaptureTheFlag is played by two teams, a team scores by bringing the enemy
+// flag to its own flag while the latter is at its base
+type CaptureTheFlag struct {
+	ScoreLimit int
+}
+
+func (mode CaptureTheFlag) OnJoin(state *GameState, playerId string) {
+	joinTeam(state, playerId)
+	if len(state.Flags) > 0 {
+		return
+	}
+	for team, base := range state.CurrentMap().flagBases() {
+		state.Flags[team] = &Flag{Base: base, Position: base}
+	}
+}
+
+func (mode CaptureTheFlag) OnMove(state *GameState, playerId string) {
+	player := state.Players[playerId]
+	location := *state.Locations[playerId]
+
+	for team, flag := range state.Flags {
+		if flag.Carrier == playerId {
+			flag.Position = location
+			continue
+		}
+		if flag.Carrier != "" || flag.Position != location {
+			continue
+		}
+		if team != player.Team {
+			flag.Carrier = playerId
+			continue
+		}
+		if !flag.isHome() {
+			flag.Position = flag.Base
+		}
+	}
+
+	ownFlag, ok := state.Flags[player.Team]
+	if !ok || !ownFlag.isHome() || ownFlag.Base != location {
+		return
+	}
+	for team, flag := range state.Flags {
+		if team != player.Team && flag.Carrier == playerId {
+			flag.Carrier = ""
+			flag.Position = flag.Base
+			state.addScore(player.Team, 1, mode.ScoreLimit)
+		}
+	}
+}
+
+func (mode CaptureTheFlag) OnKill(state *GameState, killer string, victim string, at Position) {
+	for _, flag := range state.Flags {
+		if flag.Carrier == victim {
+			flag.Carrier = ""
+			flag.Position = at
+			flag.DroppedAt = time.Now()
+		}
+	}
+}
+
+// OnTick returns the flags nobody picked up within the FlagReturnDelay
+func (mode CaptureTheFlag) OnTick(state *GameState, now time.Time) {
+	for _, flag := range state.Flags {
+		if flag.Carrier == "" && !flag.isHome() && !now.Before(flag.DroppedAt.Add(FlagReturnDelay)) {
+			flag.Position = flag.Base
+		}
+	}
+}
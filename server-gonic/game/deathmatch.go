@@ -0,0 +1,24 @@
+package game
+
+import "time"
+
+// Deathmatch is every player for themselves, the first to reach
+// the score limit in kills wins
+type Deathmatch struct {
+	ScoreLimit int
+}
+
+func (mode Deathmatch) OnJoin(state *GameState, playerId string) {
+	state.Players[playerId].Team = ""
+	if _, ok := state.Scores[playerId]; !ok {
+		state.Scores[playerId] = 0
+	}
+}
+
+func (mode Deathmatch) OnMove(state *GameState, playerId string) {}
+
+func (mode Deathmatch) OnKill(state *GameState, killer string, victim string, at Position) {
+	state.addScore(killer, 1, mode.ScoreLimit)
+}
+
+func (mode Deathmatch) OnTick(state *GameState, now time.Time) {}
@@ -0,0 +1,21 @@
+package game
+
+// RejectedAction is returned when a player is not allowed to do what they asked for.
+// Reason is a short code the client can show or translate.
+type RejectedAction struct {
+	Reason string
+}
+
+func (err RejectedAction) Error() string {
+	return "action rejected: " + err.Reason
+}
+
+var ErrPlayerNotInGame = RejectedAction{Reason: "notInGame"}
+var ErrInvalidDirection = RejectedAction{Reason: "invalidDirection"}
+var ErrWeaponCooldown = RejectedAction{Reason: "cooldown"}
+var ErrReloading = RejectedAction{Reason: "reloading"}
+var ErrEmptyMagazine = RejectedAction{Reason: "emptyMagazine"}
+var ErrMagazineFull = RejectedAction{Reason: "magazineFull"}
+var ErrNoReserveAmmo = RejectedAction{Reason: "noReserveAmmo"}
+var ErrUnknownWeapon = RejectedAction{Reason: "unknownWeapon"}
+var ErrGameFull = RejectedAction{Reason: "gameFull"}
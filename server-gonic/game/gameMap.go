@@ -0,0 +1,223 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	cellFloor     = '.'
+	cellWall      = '#'
+	cellCover     = '+'
+	cellSpawn     = 'S'
+	cellRedFlag   = 'r'
+	cellBlueFlag  = 'b'
+	cellRedSpawn  = 'R'
+	cellBlueSpawn = 'B'
+)
+
+const DefaultMapName = "default"
+
+var ErrUnknownMap = RejectedAction{Reason: "unknownMap"}
+
+// Map is the field a game is played on. The layout is a list of rows, where
+// '#' is a wall which blocks movement, shots and sight, '+' is cover which
+// blocks movement and shots, 'S' is a spawn point, 'R' and 'B' are spawn
+// points of the red and the blue team, 'r' and 'b' are their flag bases
+// and '.' is an empty cell. Colors optionally override the team colors.
+type Map struct {
+	Name   string            `json:"name"`
+	Layout []string          `json:"layout"`
+	Colors map[string]string `json:"colors"`
+
+	width       int
+	height      int
+	cells       map[Position]rune
+	spawnPoints []Position
+	teamSpawns  map[string][]Position
+	flags       map[string]Position
+}
+
+var registeredMaps = map[string]*Map{
+	DefaultMapName: mustParseMap(DefaultMapName, []string{
+		"S........S",
+		"..........",
+		"..........",
+		"..........",
+		"..........",
+		"..........",
+		"..........",
+		"..........",
+		"..........",
+		"S........S",
+	}, nil),
+}
+
+func parseMap(name string, layout []string, colors map[string]string) (*Map, error) {
+	if len(layout) == 0 {
+		return nil, errors.New("map " + name + " has an empty layout")
+	}
+
+	gameMap := &Map{
+		Name:        name,
+		Layout:      layout,
+		width:       len(layout[0]),
+		height:      len(layout),
+		cells:       map[Position]rune{},
+		spawnPoints: []Position{},
+		teamSpawns:  map[string][]Position{},
+		flags:       map[string]Position{},
+	}
+	gameMap.Colors = map[string]string{}
+	for team, color := range defaultTeamColors {
+		gameMap.Colors[team] = color
+	}
+	for team, color := range colors {
+		if isTeam(team) {
+			gameMap.Colors[team] = color
+		}
+	}
+	for y, row := range layout {
+		if len(row) != gameMap.width {
+			return nil, errors.New("rows of map " + name + " differ in width")
+		}
+		for x, cell := range row {
+			position := Position{X: x, Y: y}
+			switch cell {
+			case cellFloor:
+			case cellWall, cellCover:
+				gameMap.cells[position] = cell
+			case cellSpawn:
+				gameMap.spawnPoints = append(gameMap.spawnPoints, position)
+			case cellRedFlag:
+				gameMap.flags[TeamRed] = position
+			case cellBlueFlag:
+				gameMap.flags[TeamBlue] = position
+			case cellRedSpawn:
+				gameMap.teamSpawns[TeamRed] = append(gameMap.teamSpawns[TeamRed], position)
+			case cellBlueSpawn:
+				gameMap.teamSpawns[TeamBlue] = append(gameMap.teamSpawns[TeamBlue], position)
+			default:
+				return nil, errors.New("map " + name + " has an unknown cell " + string(cell))
+			}
+		}
+	}
+	return gameMap, nil
+}
+
+// MarshalJSON adds the size of the field to the definition of the map
+func (gameMap *Map) MarshalJSON() ([]byte, error) {
+	type definition Map
+	return json.Marshal(struct {
+		*definition
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	}{(*definition)(gameMap), gameMap.width, gameMap.height})
+}
+
+func mustParseMap(name string, layout []string, colors map[string]string) *Map {
+	gameMap, err := parseMap(name, layout, colors)
+	if err != nil {
+		panic(err)
+	}
+	return gameMap
+}
+
+// LoadMaps registers every *.json map definition found in the directory.
+// A map is named after its file unless the definition sets a name.
+func LoadMaps(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var definition Map
+		if err := json.Unmarshal(data, &definition); err != nil {
+			return errors.New(file + ": " + err.Error())
+		}
+		if definition.Name == "" {
+			definition.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		}
+
+		gameMap, err := parseMap(definition.Name, definition.Layout, definition.Colors)
+		if err != nil {
+			return err
+		}
+		registeredMaps[gameMap.Name] = gameMap
+		log.Printf("Map %s loaded", gameMap.Name)
+	}
+	return nil
+}
+
+func HasMap(name string) bool {
+	_, ok := registeredMaps[name]
+	return ok
+}
+
+func getMap(name string) *Map {
+	gameMap, ok := registeredMaps[name]
+	if !ok {
+		return registeredMaps[DefaultMapName]
+	}
+	return gameMap
+}
+
+// spawnPointsOf returns the spawn zone of the team. Players without a team,
+// or of a team the map has no zone for, spawn on any spawn point.
+func (gameMap *Map) spawnPointsOf(team string) []Position {
+	if spawns, ok := gameMap.teamSpawns[team]; ok {
+		return spawns
+	}
+
+	spawns := append([]Position{}, gameMap.spawnPoints...)
+	for _, teamSpawns := range gameMap.teamSpawns {
+		spawns = append(spawns, teamSpawns...)
+	}
+	return spawns
+}
+
+func (gameMap *Map) isOutOfField(x int, y int) bool {
+	return x < 0 || x >= gameMap.width || y < 0 || y >= gameMap.height
+}
+
+func (gameMap *Map) blocksMovement(x int, y int) bool {
+	return gameMap.isOutOfField(x, y) || gameMap.cells[Position{X: x, Y: y}] != 0
+}
+
+func (gameMap *Map) blocksShots(x int, y int) bool {
+	return gameMap.blocksMovement(x, y)
+}
+
+func (gameMap *Map) blocksSight(x int, y int) bool {
+	return gameMap.isOutOfField(x, y) || gameMap.cells[Position{X: x, Y: y}] == cellWall
+}
+
+// flagBases returns where the flags of the teams stand. Maps without
+// flag bases get them in the first free cells of opposite corners.
+func (gameMap *Map) flagBases() map[string]Position {
+	if len(gameMap.flags) == len(Teams) {
+		return gameMap.flags
+	}
+
+	bases := map[string]Position{}
+	for i := 0; i < gameMap.width*gameMap.height; i++ {
+		x, y := i%gameMap.width, i/gameMap.width
+		if _, ok := bases[TeamRed]; !ok && !gameMap.blocksMovement(x, y) {
+			bases[TeamRed] = Position{X: x, Y: y}
+		}
+		x, y = gameMap.width-1-x, gameMap.height-1-y
+		if _, ok := bases[TeamBlue]; !ok && !gameMap.blocksMovement(x, y) {
+			bases[TeamBlue] = Position{X: x, Y: y}
+		}
+	}
+	return bases
+}
@@ -2,9 +2,8 @@ package game
 
 import (
 	"encoding/json"
-	"errors"
-	"math"
 	"math/rand"
+	"time"
 
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
@@ -15,31 +14,91 @@ type Position struct {
 	Y int `json:"y"`
 }
 type GameState struct {
-	Locations map[string]*Position
+	Locations     map[string]*Position
+	Players       map[string]*Player `json:"players"`
+	Quitters      map[string]*Player `json:"quitters"`
+	RespawnDelay  time.Duration      `json:"respawnDelay"`
+	Map           string             `json:"map"`
+	Mode          string             `json:"mode"`
+	Scores        map[string]int     `json:"scores"`
+	Flags         map[string]*Flag   `json:"flags"`
+	Winner        string             `json:"winner"`
+	Phase         string             `json:"phase"`
+	PhaseEndsAt   time.Time          `json:"phaseEndsAt"`
+	StartedAt     time.Time          `json:"startedAt"`
+	FriendlyFire  bool               `json:"friendlyFire"`
+	BotDifficulty string             `json:"botDifficulty"`
 }
 
-const fieldWidth = 9
-const fieldHeight = 9
-
-func (state *GameState) AddPlayer(playerId string) {
-	if slices.Contains(maps.Keys(state.Locations), playerId) {
-		return
+// SetMap chooses the map the game is played on
+func (state *GameState) SetMap(name string) error {
+	if !HasMap(name) {
+		return ErrUnknownMap
 	}
+	state.Map = name
+	return nil
+}
 
-	state.Locations[playerId], _ = state.randomLocation()
+// CurrentMap returns the map the game is played on
+func (state *GameState) CurrentMap() *Map {
+	return getMap(state.Map)
 }
 
+// AddPlayer puts a new player on the field. The team the player asks for
+// is only kept in team modes, and only if it doesn't unbalance the teams.
+// A player who finds no free cell is not added.
+func (state *GameState) AddPlayer(playerId string, weapons []Weapon, team string) error {
+	if slices.Contains(maps.Keys(state.Players), playerId) {
+		return nil
+	}
+	delete(state.Quitters, playerId)
+
+	player := newPlayer(weapons)
+	player.Team = team
+	state.Players[playerId] = player
+	_, hadScore := state.Scores[playerId]
+	state.currentMode().OnJoin(state, playerId)
+	location, err := state.randomLocation(playerId)
+	if err != nil {
+		delete(state.Players, playerId)
+		if !hadScore {
+			delete(state.Scores, playerId)
+		}
+		return err
+	}
+	state.Locations[playerId] = location
+	return nil
+}
+
+// RemovePlayer takes the player off the field. Humans leaving a running match
+// are kept as quitters, so their results still count when it ends.
 func (state *GameState) RemovePlayer(playerId string) {
+	if player, ok := state.Players[playerId]; ok && player.Bot == nil && state.isRunning() {
+		if state.Quitters == nil {
+			state.Quitters = map[string]*Player{}
+		}
+		state.Quitters[playerId] = player
+	}
 	delete(state.Locations, playerId)
+	delete(state.Players, playerId)
+	for _, flag := range state.Flags {
+		if flag.Carrier == playerId {
+			flag.Carrier = ""
+			flag.Position = flag.Base
+		}
+	}
 }
 
-func (state *GameState) MovePlayer(player string, step Position) *Position {
+func (state *GameState) MovePlayer(player string, step Position) (*Position, error) {
 	location := state.Locations[player]
+	if err := state.acceptsInput(); err != nil {
+		return location, err
+	}
 	x := location.X + step.X
 	y := location.Y + step.Y
 
-	if x < 0 || x > fieldWidth || y < 0 || y > fieldHeight {
-		return location
+	if state.CurrentMap().blocksMovement(x, y) {
+		return location, nil
 	}
 
 	for playmate, position := range state.Locations {
@@ -48,36 +107,62 @@ func (state *GameState) MovePlayer(player string, step Position) *Position {
 		}
 
 		if position.X == x && position.Y == y {
-			return location
+			return location, nil
 		}
 	}
 	location.X = x
 	location.Y = y
-	return location
+	state.currentMode().OnMove(state, player)
+	return location, nil
 }
 
-func (state *GameState) randomLocation() (*Position, error) {
-	occupied := []int{}
-	open := []int{}
-	for _, position := range state.Locations {
-		occupied = append(occupied, position.Y*fieldWidth+position.X)
+// randomLocation picks a free spawn point of the map for the player,
+// or any free cell when all spawn points are taken
+func (state *GameState) randomLocation(playerId string) (*Position, error) {
+	field := state.CurrentMap()
+	occupied := maps.Values(state.Locations)
+	isFree := func(position Position) bool {
+		return !slices.ContainsFunc(occupied, func(location *Position) bool {
+			return *location == position
+		})
 	}
-	for i := 0; i < fieldWidth*fieldHeight; i++ {
-		if !slices.Contains(occupied, i) {
-			open = append(open, i)
+
+	open := []Position{}
+	for _, position := range field.spawnPointsOf(state.Players[playerId].Team) {
+		if isFree(position) {
+			open = append(open, position)
 		}
 	}
 	if len(open) == 0 {
-		return nil, errors.New("")
+		for y := 0; y < field.height; y++ {
+			for x := 0; x < field.width; x++ {
+				position := Position{X: x, Y: y}
+				if !field.blocksMovement(x, y) && isFree(position) {
+					open = append(open, position)
+				}
+			}
+		}
 	}
-	randomIndex := open[rand.Intn(len(open))]
-	return &Position{Y: int(math.Floor(float64(randomIndex) / fieldWidth)), X: randomIndex % fieldWidth}, nil
+	if len(open) == 0 {
+		return nil, ErrGameFull
+	}
+	location := open[rand.Intn(len(open))]
+	return &location, nil
 }
 
 func NewGame() *GameState {
-	locations := map[string]*Position{}
-
-	return &GameState{Locations: locations}
+	return &GameState{
+		Locations:     map[string]*Position{},
+		Players:       map[string]*Player{},
+		Quitters:      map[string]*Player{},
+		RespawnDelay:  DefaultRespawnDelay,
+		Map:           DefaultMapName,
+		Mode:          DefaultModeName,
+		Scores:        map[string]int{},
+		Flags:         map[string]*Flag{},
+		Phase:         PhaseWarmup,
+		BotDifficulty: DefaultBotDifficulty,
+	}
 }
 
 func (state *GameState) MarshalBinary() ([]byte, error) {
@@ -86,7 +171,20 @@ func (state *GameState) MarshalBinary() ([]byte, error) {
 		gameLocations[clientId] = *position
 	}
 	return json.Marshal(map[string]interface{}{
-		"locations": gameLocations,
+		"locations":     gameLocations,
+		"players":       state.Players,
+		"quitters":      state.Quitters,
+		"respawnDelay":  state.RespawnDelay,
+		"map":           state.Map,
+		"mode":          state.Mode,
+		"scores":        state.Scores,
+		"flags":         state.Flags,
+		"winner":        state.Winner,
+		"phase":         state.Phase,
+		"phaseEndsAt":   state.PhaseEndsAt,
+		"startedAt":     state.StartedAt,
+		"friendlyFire":  state.FriendlyFire,
+		"botDifficulty": state.BotDifficulty,
 	})
 }
 
@@ -0,0 +1,46 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestAddPlayerToFullMap(t *testing.T) {
+	state := newTestGame(t, []string{"S."})
+	for _, playerId := range []string{"a", "b"} {
+		if err := state.AddPlayer(playerId, nil, ""); err != nil {
+			t.Fatalf("adding %s: %v", playerId, err)
+		}
+	}
+
+	err := state.AddPlayer("c", nil, "")
+	if !errors.Is(err, ErrGameFull) {
+		t.Fatalf("err = %v, want %v", err, ErrGameFull)
+	}
+	if _, ok := state.Players["c"]; ok {
+		t.Error("player without a cell was added")
+	}
+	if _, ok := state.Scores["c"]; ok {
+		t.Error("player without a cell got a score")
+	}
+	if _, err := state.MarshalBinary(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMapJSONHasSize(t *testing.T) {
+	state := newTestGame(t, []string{"....", "...."})
+	data, err := json.Marshal(state.CurrentMap())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var size struct {
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	}
+	json.Unmarshal(data, &size)
+	if size.Width != 4 || size.Height != 2 {
+		t.Errorf("size = %dx%d, want 4x2", size.Width, size.Height)
+	}
+}
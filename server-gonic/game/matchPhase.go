@@ -0,0 +1,254 @@
+package game
+
+import (
+	"sort"
+	"time"
+)
+
+// A match goes through these phases in order. Once it has ended and the final
+// scoreboard was shown, the game starts over with a new warmup.
+const (
+	PhaseWarmup    = "warmup"
+	PhaseCountdown = "countdown"
+	PhaseLive      = "live"
+	PhaseOvertime  = "overtime"
+	PhaseEnded     = "ended"
+)
+
+// MinPlayers is the number of players needed for the warmup to end.
+var MinPlayers = 2
+
+// CountdownDuration is how long players wait for the match to go live.
+var CountdownDuration = 5 * time.Second
+
+// MatchDuration is how long a match is played when no one reaches the score limit.
+var MatchDuration = 5 * time.Minute
+
+// OvertimeDuration is how long a tied match is extended, the next score wins it.
+var OvertimeDuration = time.Minute
+
+// ScoreboardDuration is how long the final scoreboard is shown before the game starts over.
+var ScoreboardDuration = 10 * time.Second
+
+var ErrCountdown = RejectedAction{Reason: "countdown"}
+
+// ScoreboardEntry is the result of a single player. Score is the score
+// of the player's team in team modes. Players with equal scores share
+// their placement. Quit is set for players who left before the end,
+// they are placed after everyone who stayed.
+type ScoreboardEntry struct {
+	PlayerId    string `json:"clientId"`
+	UserID      int    `json:"userId,omitempty"`
+	UserName    string `json:"userName,omitempty"`
+	Team        string `json:"team,omitempty"`
+	Score       int    `json:"score"`
+	Kills       int    `json:"kills"`
+	Deaths      int    `json:"deaths"`
+	DamageDealt int    `json:"damageDealt"`
+	Weapon      string `json:"weapon"`
+	Placement   int    `json:"placement"`
+	Quit        bool   `json:"quit,omitempty"`
+}
+
+// Tick moves the match to its next phase once the current one is over
+// and lets the mode run its timers
+func (state *GameState) Tick(now time.Time) {
+	switch state.Phase {
+	case PhaseCountdown:
+		if len(state.Players) < MinPlayers {
+			state.setPhase(PhaseWarmup, time.Time{})
+		} else if !now.Before(state.PhaseEndsAt) {
+			state.resetMatch()
+			state.StartedAt = now
+			state.setPhase(PhaseLive, now.Add(MatchDuration))
+		}
+	case PhaseLive:
+		if now.Before(state.PhaseEndsAt) {
+			break
+		}
+		if leader, ok := state.leader(); ok {
+			state.end(leader)
+		} else {
+			state.setPhase(PhaseOvertime, now.Add(OvertimeDuration))
+		}
+	case PhaseOvertime:
+		if !now.Before(state.PhaseEndsAt) {
+			leader, _ := state.leader()
+			state.end(leader)
+		}
+	case PhaseEnded:
+		if !now.Before(state.PhaseEndsAt) {
+			state.resetMatch()
+			state.setPhase(PhaseWarmup, time.Time{})
+		}
+	default:
+		if len(state.Players) >= MinPlayers {
+			state.setPhase(PhaseCountdown, now.Add(CountdownDuration))
+		}
+	}
+
+	if state.Phase != PhaseEnded {
+		state.currentMode().OnTick(state, now)
+	}
+}
+
+// isRunning tells whether the match is being played
+func (state *GameState) isRunning() bool {
+	return state.Phase == PhaseLive || state.Phase == PhaseOvertime
+}
+
+// isPlaying tells whether the user came back to the match on another connection
+func (state *GameState) isPlaying(userId int) bool {
+	for _, player := range state.Players {
+		if userId != 0 && player.UserID == userId {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsInput tells whether players may act in the current phase
+func (state *GameState) acceptsInput() error {
+	switch state.Phase {
+	case PhaseCountdown:
+		return ErrCountdown
+	case PhaseEnded:
+		return ErrMatchEnded
+	}
+	return nil
+}
+
+func (state *GameState) setPhase(phase string, endsAt time.Time) {
+	state.Phase = phase
+	state.PhaseEndsAt = endsAt
+}
+
+// addScore gives points to a player or a team. A live match ends once the
+// score limit is reached, in overtime the first score that breaks the tie wins.
+func (state *GameState) addScore(scorer string, points int, limit int) {
+	state.Scores[scorer] += points
+	switch state.Phase {
+	case PhaseLive:
+		if state.Scores[scorer] >= limit {
+			state.end(scorer)
+		}
+	case PhaseOvertime:
+		if leader, ok := state.leader(); ok {
+			state.end(leader)
+		}
+	}
+}
+
+func (state *GameState) end(winner string) {
+	state.Winner = winner
+	state.setPhase(PhaseEnded, time.Now().Add(ScoreboardDuration))
+}
+
+// leader returns the player or team with the highest score,
+// ok is false when the top score is shared. Players who quit can't lead.
+func (state *GameState) leader() (leader string, ok bool) {
+	best := 0
+	for scorer, score := range state.Scores {
+		if _, quit := state.Quitters[scorer]; quit {
+			continue
+		}
+		if leader == "" || score > best {
+			leader, best, ok = scorer, score, true
+		} else if score == best {
+			ok = false
+		}
+	}
+	if !ok {
+		return "", false
+	}
+	return leader, true
+}
+
+// resetMatch clears the scores and the stats of the players and puts
+// everyone back to a spawn point with full health and ammo
+func (state *GameState) resetMatch() {
+	state.Scores = map[string]int{}
+	state.Flags = map[string]*Flag{}
+	state.Winner = ""
+	state.Locations = map[string]*Position{}
+	state.Quitters = map[string]*Player{}
+
+	for playerId, player := range state.Players {
+		player.Health = MaxHealth
+		player.Dead = false
+		player.RespawnAt = time.Time{}
+		player.ReloadingUntil = time.Time{}
+		player.Kills = 0
+		player.Deaths = 0
+		player.DamageDealt = 0
+		player.ShotsFired = map[string]int{}
+		for _, slot := range player.Loadout {
+			slot.refill()
+		}
+		state.currentMode().OnJoin(state, playerId)
+	}
+	for playerId := range state.Players {
+		location, err := state.randomLocation(playerId)
+		if err != nil {
+			player := state.Players[playerId]
+			player.Dead = true
+			player.RespawnAt = time.Now()
+			continue
+		}
+		state.Locations[playerId] = location
+	}
+}
+
+// Scoreboard lists the results of all players of the match, the ones who
+// quit included, best placed first
+func (state *GameState) Scoreboard() []ScoreboardEntry {
+	scoreboard := []ScoreboardEntry{}
+	addEntry := func(playerId string, player *Player, quit bool) {
+		scorer := playerId
+		if player.Team != "" {
+			scorer = player.Team
+		}
+		scoreboard = append(scoreboard, ScoreboardEntry{
+			PlayerId:    playerId,
+			UserID:      player.UserID,
+			UserName:    player.UserName,
+			Team:        player.Team,
+			Score:       state.Scores[scorer],
+			Kills:       player.Kills,
+			Deaths:      player.Deaths,
+			DamageDealt: player.DamageDealt,
+			Weapon:      player.favouriteWeapon(),
+			Quit:        quit,
+		})
+	}
+	for playerId, player := range state.Players {
+		addEntry(playerId, player, false)
+	}
+	for playerId, player := range state.Quitters {
+		if !state.isPlaying(player.UserID) {
+			addEntry(playerId, player, true)
+		}
+	}
+
+	// quitting counts as a loss, so quitters are placed after everyone who stayed
+	sort.Slice(scoreboard, func(i, j int) bool {
+		if scoreboard[i].Quit != scoreboard[j].Quit {
+			return !scoreboard[i].Quit
+		}
+		if scoreboard[i].Score != scoreboard[j].Score {
+			return scoreboard[i].Score > scoreboard[j].Score
+		}
+		if scoreboard[i].Kills != scoreboard[j].Kills {
+			return scoreboard[i].Kills > scoreboard[j].Kills
+		}
+		return scoreboard[i].Deaths < scoreboard[j].Deaths
+	})
+	for i := range scoreboard {
+		if i > 0 && scoreboard[i].Score == scoreboard[i-1].Score && scoreboard[i].Quit == scoreboard[i-1].Quit {
+			scoreboard[i].Placement = scoreboard[i-1].Placement
+		} else {
+			scoreboard[i].Placement = i + 1
+		}
+	}
+	return scoreboard
+}
@@ -0,0 +1,76 @@
+package game
+
+import "testing"
+
+func TestScoreboardKeepsQuitters(t *testing.T) {
+	tests := []struct {
+		name     string
+		phase    string
+		rejoin   bool
+		entries  int
+		quitters int
+	}{
+		{name: "quitting a live match", phase: PhaseLive, entries: 2, quitters: 1},
+		{name: "quitting in overtime", phase: PhaseOvertime, entries: 2, quitters: 1},
+		{name: "leaving during warmup", phase: PhaseWarmup, entries: 1, quitters: 0},
+		{name: "coming back on another connection", phase: PhaseLive, rejoin: true, entries: 2, quitters: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTestGame(t, []string{"S...S"})
+			for userId, playerId := range []string{"stays", "quits"} {
+				if err := state.AddPlayer(playerId, nil, ""); err != nil {
+					t.Fatal(err)
+				}
+				state.Players[playerId].UserID = userId + 1
+			}
+			state.Phase = test.phase
+
+			state.Players["quits"].Kills = 3
+			state.Scores["quits"] = 3
+			state.RemovePlayer("quits")
+			if test.rejoin {
+				state.AddPlayer("again", nil, "")
+				state.Players["again"].UserID = 2
+			}
+
+			scoreboard := state.Scoreboard()
+			if len(scoreboard) != test.entries {
+				t.Fatalf("entries = %d, want %d", len(scoreboard), test.entries)
+			}
+			quitters := 0
+			for _, entry := range scoreboard {
+				if entry.Quit {
+					quitters++
+					if entry.UserID != 2 || entry.Kills != 3 {
+						t.Errorf("quitter = %+v, want the results of user 2", entry)
+					}
+					if entry.Placement != len(scoreboard) {
+						t.Errorf("quitter placement = %d, want %d", entry.Placement, len(scoreboard))
+					}
+				}
+			}
+			if quitters != test.quitters {
+				t.Errorf("quitters = %d, want %d", quitters, test.quitters)
+			}
+		})
+	}
+}
+
+func TestQuitterCantLead(t *testing.T) {
+	state := newTestGame(t, []string{"S...S"})
+	for _, playerId := range []string{"stays", "quits"} {
+		if err := state.AddPlayer(playerId, nil, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	state.Phase = PhaseLive
+	state.Scores["quits"] = 5
+	state.Scores["stays"] = 1
+	state.RemovePlayer("quits")
+
+	if leader, ok := state.leader(); !ok || leader != "stays" {
+		t.Errorf("leader = %q, %v, want \"stays\"", leader, ok)
+	}
+}
@@ -0,0 +1,46 @@
+package game
+
+import "time"
+
+const DefaultModeName = "deathmatch"
+
+var ErrUnknownMode = RejectedAction{Reason: "unknownMode"}
+var ErrMatchEnded = RejectedAction{Reason: "matchEnded"}
+
+// GameMode holds the rules of a game: it's notified about what happens on
+// the field, keeps the score and decides when the match is over. Modes are
+// stateless, everything they track is kept in the game state.
+type GameMode interface {
+	OnJoin(state *GameState, playerId string)
+	OnMove(state *GameState, playerId string)
+	OnKill(state *GameState, killer string, victim string, at Position)
+	OnTick(state *GameState, now time.Time)
+}
+
+var registeredModes = map[string]GameMode{
+	"deathmatch":     Deathmatch{ScoreLimit: 20},
+	"teamDeathmatch": TeamDeathmatch{ScoreLimit: 50},
+	"captureTheFlag": CaptureTheFlag{ScoreLimit: 3},
+}
+
+func HasMode(name string) bool {
+	_, ok := registeredModes[name]
+	return ok
+}
+
+// SetMode chooses the rules the game is played by
+func (state *GameState) SetMode(name string) error {
+	if !HasMode(name) {
+		return ErrUnknownMode
+	}
+	state.Mode = name
+	return nil
+}
+
+func (state *GameState) currentMode() GameMode {
+	mode, ok := registeredModes[state.Mode]
+	if !ok {
+		return registeredModes[DefaultModeName]
+	}
+	return mode
+}
@@ -0,0 +1,239 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+// kill is a kill made by one player on another
+type kill struct {
+	killer string
+	victim string
+}
+
+func TestKillScoring(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   GameMode
+		teams  map[string]string
+		kills  []kill
+		phase  string
+		scores map[string]int
+		winner string
+		ended  bool
+	}{
+		{
+			name:   "deathmatch scores the killer",
+			mode:   Deathmatch{ScoreLimit: 3},
+			kills:  []kill{{"a", "b"}, {"b", "a"}, {"a", "b"}},
+			phase:  PhaseLive,
+			scores: map[string]int{"a": 2, "b": 1},
+		},
+		{
+			name:   "deathmatch ends at the score limit",
+			mode:   Deathmatch{ScoreLimit: 2},
+			kills:  []kill{{"a", "b"}, {"a", "b"}},
+			phase:  PhaseLive,
+			scores: map[string]int{"a": 2},
+			winner: "a",
+			ended:  true,
+		},
+		{
+			name:   "deathmatch doesn't end during warmup",
+			mode:   Deathmatch{ScoreLimit: 1},
+			kills:  []kill{{"a", "b"}, {"a", "b"}},
+			phase:  PhaseWarmup,
+			scores: map[string]int{"a": 2},
+		},
+		{
+			name:   "a tie in overtime is broken by the next kill",
+			mode:   Deathmatch{ScoreLimit: 10},
+			kills:  []kill{{"b", "a"}},
+			phase:  PhaseOvertime,
+			scores: map[string]int{"b": 1},
+			winner: "b",
+			ended:  true,
+		},
+		{
+			name:   "team deathmatch scores the killer's team",
+			mode:   TeamDeathmatch{ScoreLimit: 5},
+			teams:  map[string]string{"a": TeamRed, "b": TeamBlue, "c": TeamBlue},
+			kills:  []kill{{"a", "b"}, {"c", "a"}, {"a", "c"}},
+			phase:  PhaseLive,
+			scores: map[string]int{TeamRed: 2, TeamBlue: 1},
+		},
+		{
+			name:   "team deathmatch doesn't score teamkills",
+			mode:   TeamDeathmatch{ScoreLimit: 5},
+			teams:  map[string]string{"a": TeamBlue, "b": TeamBlue},
+			kills:  []kill{{"a", "b"}},
+			phase:  PhaseLive,
+			scores: map[string]int{TeamBlue: 0},
+		},
+		{
+			name:   "team deathmatch ends at the score limit",
+			mode:   TeamDeathmatch{ScoreLimit: 2},
+			teams:  map[string]string{"a": TeamRed, "b": TeamBlue, "c": TeamRed},
+			kills:  []kill{{"a", "b"}, {"c", "b"}},
+			phase:  PhaseLive,
+			scores: map[string]int{TeamRed: 2},
+			winner: TeamRed,
+			ended:  true,
+		},
+		{
+			name:   "capture the flag doesn't score kills",
+			mode:   CaptureTheFlag{ScoreLimit: 1},
+			teams:  map[string]string{"a": TeamRed, "b": TeamBlue},
+			kills:  []kill{{"a", "b"}},
+			phase:  PhaseLive,
+			scores: map[string]int{TeamRed: 0, TeamBlue: 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := NewGame()
+			state.Phase = test.phase
+			for _, playerId := range []string{"a", "b", "c"} {
+				state.Players[playerId] = newPlayer(nil)
+				state.Players[playerId].Team = test.teams[playerId]
+			}
+			for _, k := range test.kills {
+				test.mode.OnKill(state, k.killer, k.victim, Position{})
+			}
+
+			for scorer, score := range test.scores {
+				if state.Scores[scorer] != score {
+					t.Errorf("score of %s = %d, want %d", scorer, state.Scores[scorer], score)
+				}
+			}
+			if ended := state.Phase == PhaseEnded; ended != test.ended {
+				t.Errorf("ended = %v, want %v", ended, test.ended)
+			}
+			if state.Winner != test.winner {
+				t.Errorf("winner = %q, want %q", state.Winner, test.winner)
+			}
+		})
+	}
+}
+
+func TestCaptureTheFlag(t *testing.T) {
+	layout := []string{
+		"r...b",
+		".....",
+	}
+	redBase := Position{X: 0, Y: 0}
+	blueBase := Position{X: 4, Y: 0}
+
+	tests := []struct {
+		name string
+		// play moves player "red" of the red team, "blue" is its enemy
+		play       func(state *GameState, mode CaptureTheFlag, move func(Position))
+		redScore   int
+		blueFlagAt Position
+		carrier    string
+		ended      bool
+	}{
+		{
+			name: "touching the enemy flag picks it up",
+			play: func(state *GameState, mode CaptureTheFlag, move func(Position)) {
+				move(blueBase)
+				move(Position{X: 3, Y: 1})
+			},
+			blueFlagAt: Position{X: 3, Y: 1},
+			carrier:    "red",
+		},
+		{
+			name: "bringing the enemy flag home scores",
+			play: func(state *GameState, mode CaptureTheFlag, move func(Position)) {
+				move(blueBase)
+				move(redBase)
+			},
+			redScore:   1,
+			blueFlagAt: blueBase,
+		},
+		{
+			name: "the last capture ends the match",
+			play: func(state *GameState, mode CaptureTheFlag, move func(Position)) {
+				move(blueBase)
+				move(redBase)
+				move(blueBase)
+				move(redBase)
+			},
+			redScore:   2,
+			blueFlagAt: blueBase,
+			ended:      true,
+		},
+		{
+			name: "nothing is scored while the own flag is away",
+			play: func(state *GameState, mode CaptureTheFlag, move func(Position)) {
+				state.Flags[TeamRed].Carrier = "blue"
+				move(blueBase)
+				move(redBase)
+			},
+			blueFlagAt: redBase,
+			carrier:    "red",
+		},
+		{
+			name: "killing the carrier drops the flag",
+			play: func(state *GameState, mode CaptureTheFlag, move func(Position)) {
+				move(blueBase)
+				move(Position{X: 2, Y: 1})
+				mode.OnKill(state, "blue", "red", Position{X: 2, Y: 1})
+			},
+			blueFlagAt: Position{X: 2, Y: 1},
+		},
+		{
+			name: "a dropped flag returns after the delay",
+			play: func(state *GameState, mode CaptureTheFlag, move func(Position)) {
+				move(blueBase)
+				move(Position{X: 2, Y: 1})
+				mode.OnKill(state, "blue", "red", Position{X: 2, Y: 1})
+				mode.OnTick(state, time.Now().Add(FlagReturnDelay))
+			},
+			blueFlagAt: blueBase,
+		},
+		{
+			name: "a dropped flag stays before the delay",
+			play: func(state *GameState, mode CaptureTheFlag, move func(Position)) {
+				move(blueBase)
+				move(Position{X: 2, Y: 1})
+				mode.OnKill(state, "blue", "red", Position{X: 2, Y: 1})
+				mode.OnTick(state, time.Now().Add(FlagReturnDelay/2))
+			},
+			blueFlagAt: Position{X: 2, Y: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTestGame(t, layout)
+			state.Phase = PhaseLive
+			mode := CaptureTheFlag{ScoreLimit: 2}
+			for playerId, team := range map[string]string{"red": TeamRed, "blue": TeamBlue} {
+				state.Players[playerId] = newPlayer(nil)
+				state.Players[playerId].Team = team
+				mode.OnJoin(state, playerId)
+			}
+			state.Locations["red"] = &Position{X: 1, Y: 1}
+			move := func(to Position) {
+				*state.Locations["red"] = to
+				mode.OnMove(state, "red")
+			}
+
+			test.play(state, mode, move)
+
+			if state.Scores[TeamRed] != test.redScore {
+				t.Errorf("red score = %d, want %d", state.Scores[TeamRed], test.redScore)
+			}
+			blueFlag := state.Flags[TeamBlue]
+			if blueFlag.Position != test.blueFlagAt || blueFlag.Carrier != test.carrier {
+				t.Errorf("blue flag at %v carried by %q, want at %v carried by %q",
+					blueFlag.Position, blueFlag.Carrier, test.blueFlagAt, test.carrier)
+			}
+			if ended := state.Phase == PhaseEnded; ended != test.ended {
+				t.Errorf("ended = %v, want %v", ended, test.ended)
+			}
+		})
+	}
+}
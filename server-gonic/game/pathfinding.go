@@ -0,0 +1,37 @@
+package game
+
+// nextStep finds the shortest way from one cell to another with a breadth
+// first search and returns the first step of it. Players don't block the way,
+// a step into an occupied cell simply fails when it's made.
+func (gameMap *Map) nextStep(from Position, to Position) (Position, bool) {
+	if from == to || gameMap.blocksMovement(to.X, to.Y) {
+		return Position{}, false
+	}
+
+	cameFrom := map[Position]Position{from: from}
+	queue := []Position{from}
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		if cell == to {
+			break
+		}
+		for _, direction := range directions {
+			next := Position{X: cell.X + direction.X, Y: cell.Y + direction.Y}
+			if _, seen := cameFrom[next]; seen || gameMap.blocksMovement(next.X, next.Y) {
+				continue
+			}
+			cameFrom[next] = cell
+			queue = append(queue, next)
+		}
+	}
+
+	if _, reached := cameFrom[to]; !reached {
+		return Position{}, false
+	}
+	cell := to
+	for cameFrom[cell] != from {
+		cell = cameFrom[cell]
+	}
+	return Position{X: cell.X - from.X, Y: cell.Y - from.Y}, true
+}
@@ -0,0 +1,167 @@
+package game
+
+import "time"
+
+const MaxHealth = 100
+
+// DefaultRespawnDelay is used by games created with NewGame.
+var DefaultRespawnDelay = 3 * time.Second
+
+// Player holds the in-game state of a participant which is not tied to the grid.
+type Player struct {
+	Health         int            `json:"health"`
+	Dead           bool           `json:"dead"`
+	RespawnAt      time.Time      `json:"respawnAt"`
+	Loadout        []*WeaponSlot  `json:"loadout"`
+	ActiveWeapon   int            `json:"activeWeapon"`
+	LastShotAt     time.Time      `json:"lastShotAt"`
+	ReloadingUntil time.Time      `json:"reloadingUntil"`
+	Team           string         `json:"team"`
+	Kills          int            `json:"kills"`
+	Deaths         int            `json:"deaths"`
+	DamageDealt    int            `json:"damageDealt"`
+	ShotsFired     map[string]int `json:"shotsFired"`
+	Bot            *Bot           `json:"bot,omitempty"`
+	UserID         int            `json:"userId,omitempty"`
+	UserName       string         `json:"userName,omitempty"`
+}
+
+func newPlayer(weapons []Weapon) *Player {
+	if len(weapons) == 0 {
+		weapons = []Weapon{DefaultWeapon}
+	}
+	loadout := []*WeaponSlot{}
+	for _, weapon := range weapons {
+		loadout = append(loadout, newWeaponSlot(weapon))
+	}
+	return &Player{Health: MaxHealth, Loadout: loadout, ShotsFired: map[string]int{}}
+}
+
+// favouriteWeapon is the title of the weapon the player fired most often
+func (player *Player) favouriteWeapon() string {
+	favourite := ""
+	for title, shots := range player.ShotsFired {
+		if favourite == "" || shots > player.ShotsFired[favourite] {
+			favourite = title
+		}
+	}
+	return favourite
+}
+
+func (player *Player) activeSlot() *WeaponSlot {
+	return player.Loadout[player.ActiveWeapon]
+}
+
+// SwitchWeapon makes the loadout slot with the given index the active one.
+func (state *GameState) SwitchWeapon(playerId string, weapon int) (*WeaponSlot, error) {
+	player, ok := state.Players[playerId]
+	if !ok || player.Dead {
+		return nil, ErrPlayerNotInGame
+	}
+	if err := state.acceptsInput(); err != nil {
+		return nil, err
+	}
+	if weapon < 0 || weapon >= len(player.Loadout) {
+		return nil, ErrUnknownWeapon
+	}
+	if time.Now().Before(player.ReloadingUntil) {
+		return nil, ErrReloading
+	}
+
+	player.ActiveWeapon = weapon
+	return player.activeSlot(), nil
+}
+
+// Reload moves ammo from the reserve into the magazine of the active weapon.
+// The weapon can't fire until the reload is over.
+func (state *GameState) Reload(playerId string) (*WeaponSlot, error) {
+	player, ok := state.Players[playerId]
+	if !ok || player.Dead {
+		return nil, ErrPlayerNotInGame
+	}
+	if err := state.acceptsInput(); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if now.Before(player.ReloadingUntil) {
+		return nil, ErrReloading
+	}
+	slot := player.activeSlot()
+	if slot.Magazine == slot.Weapon.MagazineSize {
+		return nil, ErrMagazineFull
+	}
+	if slot.Reserve == 0 {
+		return nil, ErrNoReserveAmmo
+	}
+
+	loaded := slot.Weapon.MagazineSize - slot.Magazine
+	if loaded > slot.Reserve {
+		loaded = slot.Reserve
+	}
+	slot.Magazine += loaded
+	slot.Reserve -= loaded
+	player.ReloadingUntil = now.Add(ReloadDuration)
+	return slot, nil
+}
+
+// DamagePlayer reduces the victim's health. A player whose health drops to zero
+// dies and leaves the grid until the respawn delay has passed. The damage and
+// the kill are added to the stats of the attacker unless the victim is a teammate.
+func (state *GameState) DamagePlayer(attacker string, playerId string, damage int) (killed bool) {
+	player, ok := state.Players[playerId]
+	if !ok || player.Dead {
+		return false
+	}
+
+	dealt := damage
+	if dealt > player.Health {
+		dealt = player.Health
+	}
+	shooter, ok := state.Players[attacker]
+	counts := ok && attacker != playerId && !state.areTeammates(attacker, playerId)
+	if counts {
+		shooter.DamageDealt += dealt
+	}
+
+	player.Health -= damage
+	if player.Health > 0 {
+		return false
+	}
+
+	player.Health = 0
+	player.Dead = true
+	player.Deaths++
+	if counts {
+		shooter.Kills++
+	}
+	player.RespawnAt = time.Now().Add(state.RespawnDelay)
+	location := *state.Locations[playerId]
+	delete(state.Locations, playerId)
+	state.currentMode().OnKill(state, attacker, playerId, location)
+	return true
+}
+
+// RespawnPlayers brings back every dead player whose respawn time has come
+// and returns their ids. Players stay dead while there is no free cell.
+func (state *GameState) RespawnPlayers(now time.Time) []string {
+	respawned := []string{}
+	for playerId, player := range state.Players {
+		if !player.Dead || player.RespawnAt.After(now) {
+			continue
+		}
+		location, err := state.randomLocation(playerId)
+		if err != nil {
+			break
+		}
+		state.Locations[playerId] = location
+		player.Health = MaxHealth
+		player.Dead = false
+		player.RespawnAt = time.Time{}
+		player.ReloadingUntil = time.Time{}
+		for _, slot := range player.Loadout {
+			slot.refill()
+		}
+		respawned = append(respawned, playerId)
+	}
+	return respawned
+}
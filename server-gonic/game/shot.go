@@ -0,0 +1,107 @@
+package game
+
+import (
+	"time"
+)
+
+// Shot describes a single fired shot: the cells its pellets passed
+// through and the players they hit.
+type Shot struct {
+	Shooter  string     `json:"shooter"`
+	Weapon   string     `json:"weapon"`
+	Magazine int        `json:"magazine"`
+	Path     []Position `json:"path"`
+	Hits     []Hit      `json:"hits"`
+}
+
+// Hit is the total damage a shot dealt to one player.
+type Hit struct {
+	Victim string `json:"victim"`
+	Damage int    `json:"damage"`
+	Killed bool   `json:"killed"`
+}
+
+// Shoot fires the player's weapon in the given direction. Every pellet
+// travels cell by cell until it leaves the field, hits a wall or cover,
+// runs out of range or hits another player, who takes the weapon's damage.
+// Teammates stop pellets too, but are only hurt with friendly fire on.
+func (state *GameState) Shoot(shooter string, direction Position) (*Shot, error) {
+	location, isInGame := state.Locations[shooter]
+	if !isInGame {
+		return nil, ErrPlayerNotInGame
+	}
+	if err := state.acceptsInput(); err != nil {
+		return nil, err
+	}
+	if direction.X < -1 || direction.X > 1 || direction.Y < -1 || direction.Y > 1 ||
+		(direction.X == 0 && direction.Y == 0) {
+		return nil, ErrInvalidDirection
+	}
+
+	player := state.Players[shooter]
+	slot := player.activeSlot()
+	weapon := slot.Weapon
+	now := time.Now()
+	if now.Before(player.ReloadingUntil) {
+		return nil, ErrReloading
+	}
+	if slot.Magazine == 0 {
+		return nil, ErrEmptyMagazine
+	}
+	if now.Before(player.LastShotAt.Add(weapon.Cooldown)) {
+		return nil, ErrWeaponCooldown
+	}
+	player.LastShotAt = now
+	slot.Magazine--
+	if player.ShotsFired == nil {
+		player.ShotsFired = map[string]int{}
+	}
+	player.ShotsFired[weapon.Title]++
+
+	field := state.CurrentMap()
+	shot := &Shot{Shooter: shooter, Weapon: weapon.Title, Magazine: slot.Magazine, Path: []Position{}, Hits: []Hit{}}
+	hits := map[string]*Hit{}
+	for _, pellet := range weapon.pelletDirections(direction) {
+		x := location.X
+		y := location.Y
+		for distance := 1; distance <= weapon.Range; distance++ {
+			x += pellet.X
+			y += pellet.Y
+			if field.blocksShots(x, y) {
+				break
+			}
+			shot.Path = append(shot.Path, Position{X: x, Y: y})
+			victim := state.playerAt(x, y)
+			if victim == "" {
+				continue
+			}
+			if !state.FriendlyFire && state.areTeammates(shooter, victim) {
+				break
+			}
+
+			hit, ok := hits[victim]
+			if !ok {
+				hit = &Hit{Victim: victim}
+				hits[victim] = hit
+			}
+			hit.Damage += weapon.Damage
+			if state.DamagePlayer(shooter, victim, weapon.Damage) {
+				hit.Killed = true
+			}
+			break
+		}
+	}
+	for _, hit := range hits {
+		shot.Hits = append(shot.Hits, *hit)
+	}
+	return shot, nil
+}
+
+func (state *GameState) playerAt(x int, y int) string {
+	for player, position := range state.Locations {
+		if position.X == x && position.Y == y {
+			return player
+		}
+	}
+	return ""
+}
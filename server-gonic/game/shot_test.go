@@ -0,0 +1,282 @@
+package game
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// newTestGame returns a game on a map registered under the test's name
+func newTestGame(t *testing.T, layout []string) *GameState {
+	t.Helper()
+	gameMap, err := parseMap(t.Name(), layout, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registeredMaps[gameMap.Name] = gameMap
+	t.Cleanup(func() { delete(registeredMaps, gameMap.Name) })
+
+	state := NewGame()
+	state.Map = gameMap.Name
+	return state
+}
+
+// placePlayer puts a player on the given cell without going through spawning
+func placePlayer(state *GameState, playerId string, at Position, weapon Weapon) *Player {
+	player := newPlayer([]Weapon{weapon})
+	state.Players[playerId] = player
+	state.Locations[playerId] = &at
+	return player
+}
+
+func TestShootPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		layout    []string
+		from      Position
+		direction Position
+		path      []Position
+	}{
+		{
+			name:      "runs to the end of the range",
+			layout:    []string{"..........", ".........."},
+			from:      Position{X: 0, Y: 0},
+			direction: Position{X: 1, Y: 0},
+			path:      []Position{{1, 0}, {2, 0}, {3, 0}, {4, 0}, {5, 0}, {6, 0}},
+		},
+		{
+			name:      "stops at the edge of the field",
+			layout:    []string{"....", "...."},
+			from:      Position{X: 1, Y: 0},
+			direction: Position{X: 1, Y: 0},
+			path:      []Position{{2, 0}, {3, 0}},
+		},
+		{
+			name:      "follows the diagonal",
+			layout:    []string{"....", "....", "...."},
+			from:      Position{X: 0, Y: 0},
+			direction: Position{X: 1, Y: 1},
+			path:      []Position{{1, 1}, {2, 2}},
+		},
+		{
+			name:      "is stopped by a wall",
+			layout:    []string{"...#......"},
+			from:      Position{X: 0, Y: 0},
+			direction: Position{X: 1, Y: 0},
+			path:      []Position{{1, 0}, {2, 0}},
+		},
+		{
+			name:      "is stopped by cover",
+			layout:    []string{".+........"},
+			from:      Position{X: 0, Y: 0},
+			direction: Position{X: 1, Y: 0},
+			path:      []Position{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTestGame(t, test.layout)
+			placePlayer(state, "shooter", test.from, DefaultWeapon)
+
+			shot, err := state.Shoot("shooter", test.direction)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(shot.Path, test.path) {
+				t.Errorf("path = %v, want %v", shot.Path, test.path)
+			}
+			if len(shot.Hits) != 0 {
+				t.Errorf("hits = %v, want none", shot.Hits)
+			}
+		})
+	}
+}
+
+func TestShootWallProtectsPlayer(t *testing.T) {
+	state := newTestGame(t, []string{"..#.."})
+	placePlayer(state, "shooter", Position{X: 0, Y: 0}, DefaultWeapon)
+	victim := placePlayer(state, "victim", Position{X: 4, Y: 0}, DefaultWeapon)
+
+	shot, err := state.Shoot("shooter", Position{X: 1, Y: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shot.Hits) != 0 || victim.Health != MaxHealth {
+		t.Errorf("hits = %v, health = %d, want no hit behind the wall", shot.Hits, victim.Health)
+	}
+}
+
+func TestShootHits(t *testing.T) {
+	shotgun := Weapon{Title: "Shotgun", Damage: 30, Range: 3, MagazineSize: 2, Spread: 1}
+	tests := []struct {
+		name         string
+		weapon       Weapon
+		victims      map[string]Position
+		health       int
+		friendlyFire bool
+		teams        bool
+		hits         map[string]Hit
+		pathEnd      Position
+	}{
+		{
+			name:    "damages the first player in the way",
+			weapon:  DefaultWeapon,
+			victims: map[string]Position{"near": {3, 2}, "far": {5, 2}},
+			health:  MaxHealth,
+			hits:    map[string]Hit{"near": {Victim: "near", Damage: 20}},
+			pathEnd: Position{3, 2},
+		},
+		{
+			name:    "kills a player without health left",
+			weapon:  DefaultWeapon,
+			victims: map[string]Position{"near": {3, 2}},
+			health:  20,
+			hits:    map[string]Hit{"near": {Victim: "near", Damage: 20, Killed: true}},
+			pathEnd: Position{3, 2},
+		},
+		{
+			name:    "pellets of a spread hit every player they reach",
+			weapon:  shotgun,
+			victims: map[string]Position{"up": {2, 1}, "straight": {2, 2}, "down": {2, 3}},
+			health:  MaxHealth,
+			hits: map[string]Hit{
+				"up":       {Victim: "up", Damage: 30},
+				"straight": {Victim: "straight", Damage: 30},
+				"down":     {Victim: "down", Damage: 30},
+			},
+			pathEnd: Position{2, 1},
+		},
+		{
+			name:    "teammates block without friendly fire",
+			weapon:  DefaultWeapon,
+			victims: map[string]Position{"mate": {3, 2}, "enemy": {5, 2}},
+			health:  MaxHealth,
+			teams:   true,
+			hits:    map[string]Hit{},
+			pathEnd: Position{3, 2},
+		},
+		{
+			name:         "teammates are hurt with friendly fire",
+			weapon:       DefaultWeapon,
+			victims:      map[string]Position{"mate": {3, 2}},
+			health:       MaxHealth,
+			teams:        true,
+			friendlyFire: true,
+			hits:         map[string]Hit{"mate": {Victim: "mate", Damage: 20}},
+			pathEnd:      Position{3, 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTestGame(t, []string{"........", "........", "........", "........", "........"})
+			state.FriendlyFire = test.friendlyFire
+			shooter := placePlayer(state, "shooter", Position{X: 1, Y: 2}, test.weapon)
+			for victimId, at := range test.victims {
+				victim := placePlayer(state, victimId, at, DefaultWeapon)
+				victim.Health = test.health
+				if test.teams {
+					victim.Team = TeamBlue
+				}
+			}
+			if test.teams {
+				shooter.Team = TeamBlue
+			}
+			if enemy, ok := state.Players["enemy"]; ok {
+				enemy.Team = TeamRed
+			}
+
+			shot, err := state.Shoot("shooter", Position{X: 1, Y: 0})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(shot.Hits) != len(test.hits) {
+				t.Fatalf("hits = %v, want %v", shot.Hits, test.hits)
+			}
+			for _, hit := range shot.Hits {
+				if hit != test.hits[hit.Victim] {
+					t.Errorf("hit = %v, want %v", hit, test.hits[hit.Victim])
+				}
+				victim := state.Players[hit.Victim]
+				if victim.Health != test.health-hit.Damage && !(hit.Killed && victim.Health == 0) {
+					t.Errorf("health of %s = %d after %d damage", hit.Victim, victim.Health, hit.Damage)
+				}
+				if _, onField := state.Locations[hit.Victim]; onField == hit.Killed {
+					t.Errorf("%s on field = %v, killed = %v", hit.Victim, onField, hit.Killed)
+				}
+			}
+			if !slices.Contains(shot.Path, test.pathEnd) {
+				t.Errorf("path = %v, want it to reach %v", shot.Path, test.pathEnd)
+			}
+			if killed := test.hits["near"].Killed; killed && shooter.Kills != 1 {
+				t.Errorf("kills = %d, want 1", shooter.Kills)
+			}
+		})
+	}
+}
+
+func TestShootRejected(t *testing.T) {
+	tests := []struct {
+		name      string
+		prepare   func(state *GameState, shooter *Player)
+		direction Position
+		err       error
+	}{
+		{
+			name:      "direction out of range",
+			direction: Position{X: 2, Y: 0},
+			err:       ErrInvalidDirection,
+		},
+		{
+			name:      "no direction",
+			direction: Position{X: 0, Y: 0},
+			err:       ErrInvalidDirection,
+		},
+		{
+			name:      "empty magazine",
+			prepare:   func(state *GameState, shooter *Player) { shooter.activeSlot().Magazine = 0 },
+			direction: Position{X: 1, Y: 0},
+			err:       ErrEmptyMagazine,
+		},
+		{
+			name:      "weapon cooling down",
+			prepare:   func(state *GameState, shooter *Player) { shooter.LastShotAt = time.Now() },
+			direction: Position{X: 1, Y: 0},
+			err:       ErrWeaponCooldown,
+		},
+		{
+			name:      "reloading",
+			prepare:   func(state *GameState, shooter *Player) { shooter.ReloadingUntil = time.Now().Add(time.Second) },
+			direction: Position{X: 1, Y: 0},
+			err:       ErrReloading,
+		},
+		{
+			name:      "dead shooter",
+			prepare:   func(state *GameState, shooter *Player) { delete(state.Locations, "shooter") },
+			direction: Position{X: 1, Y: 0},
+			err:       ErrPlayerNotInGame,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTestGame(t, []string{"....."})
+			shooter := placePlayer(state, "shooter", Position{X: 0, Y: 0}, DefaultWeapon)
+			if test.prepare != nil {
+				test.prepare(state, shooter)
+			}
+			magazine := shooter.activeSlot().Magazine
+
+			_, err := state.Shoot("shooter", test.direction)
+			if !errors.Is(err, test.err) {
+				t.Errorf("err = %v, want %v", err, test.err)
+			}
+			if shooter.activeSlot().Magazine != magazine {
+				t.Errorf("magazine = %d, want the rejected shot not to use ammo", shooter.activeSlot().Magazine)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package game
+
+const TeamRed = "red"
+const TeamBlue = "blue"
+
+var Teams = []string{TeamRed, TeamBlue}
+
+var defaultTeamColors = map[string]string{
+	TeamRed:  "#e53935",
+	TeamBlue: "#1e88e5",
+}
+
+func isTeam(team string) bool {
+	for _, known := range Teams {
+		if known == team {
+			return true
+		}
+	}
+	return false
+}
+
+// teamSizes counts the players of every team, leaving out the given player
+func (state *GameState) teamSizes(except string) map[string]int {
+	sizes := map[string]int{}
+	for playerId, player := range state.Players {
+		if playerId != except {
+			sizes[player.Team]++
+		}
+	}
+	return sizes
+}
+
+// assignTeam keeps the team the player chose as long as it's not bigger than
+// the other one, otherwise the player is balanced into the smallest team
+func (state *GameState) assignTeam(playerId string) {
+	player := state.Players[playerId]
+	sizes := state.teamSizes(playerId)
+
+	smallest := Teams[0]
+	for _, team := range Teams[1:] {
+		if sizes[team] < sizes[smallest] {
+			smallest = team
+		}
+	}
+	if !isTeam(player.Team) || sizes[player.Team] > sizes[smallest] {
+		player.Team = smallest
+	}
+}
+
+func (state *GameState) areTeammates(player string, other string) bool {
+	first, ok := state.Players[player]
+	if !ok || first.Team == "" {
+		return false
+	}
+	second, ok := state.Players[other]
+	return ok && first.Team == second.Team
+}
@@ -0,0 +1,34 @@
+package game
+
+import "time"
+
+// TeamDeathmatch splits the players into two teams, the first team
+// to reach the score limit in kills wins. Killing a teammate scores nothing.
+type TeamDeathmatch struct {
+	ScoreLimit int
+}
+
+func (mode TeamDeathmatch) OnJoin(state *GameState, playerId string) {
+	joinTeam(state, playerId)
+}
+
+func (mode TeamDeathmatch) OnMove(state *GameState, playerId string) {}
+
+func (mode TeamDeathmatch) OnKill(state *GameState, killer string, victim string, at Position) {
+	if state.areTeammates(killer, victim) {
+		return
+	}
+	state.addScore(state.Players[killer].Team, 1, mode.ScoreLimit)
+}
+
+func (mode TeamDeathmatch) OnTick(state *GameState, now time.Time) {}
+
+// joinTeam puts the player into a team and makes sure every team has a score
+func joinTeam(state *GameState, playerId string) {
+	state.assignTeam(playerId)
+	for _, team := range Teams {
+		if _, ok := state.Scores[team]; !ok {
+			state.Scores[team] = 0
+		}
+	}
+}
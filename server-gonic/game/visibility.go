@@ -0,0 +1,145 @@
+package game
+
+import "golang.org/x/exp/slices"
+
+// CanSee tells whether the viewer has a line of sight to the target. Only
+// walls block the sight, players can be seen over cover and other players.
+// Dead players are not on the field, they neither see nor can be seen.
+func (state *GameState) CanSee(viewer string, target string) bool {
+	from, ok := state.Locations[viewer]
+	if !ok {
+		return false
+	}
+	to, ok := state.Locations[target]
+	if !ok {
+		return false
+	}
+	if viewer == target {
+		return true
+	}
+	return state.canSeeFrom(*from, *to)
+}
+
+// CanSeeCell tells whether the viewer has a line of sight to the cell
+func (state *GameState) CanSeeCell(viewer string, cell Position) bool {
+	from, ok := state.Locations[viewer]
+	if !ok {
+		return false
+	}
+	return state.canSeeFrom(*from, cell)
+}
+
+func (state *GameState) canSeeFrom(from Position, to Position) bool {
+	if from == to {
+		return true
+	}
+	field := state.CurrentMap()
+	for _, cell := range lineOfSight(from, to) {
+		if field.blocksSight(cell.X, cell.Y) {
+			return false
+		}
+	}
+	return true
+}
+
+// ShotWitnesses returns the players who see the shooter or any cell the shot
+// passed through, together with the shooter and the players it hit. It has to
+// be called while the shooter is still on the field.
+func (state *GameState) ShotWitnesses(shot *Shot) []string {
+	witnesses := []string{shot.Shooter}
+	for _, hit := range shot.Hits {
+		if hit.Victim != shot.Shooter {
+			witnesses = append(witnesses, hit.Victim)
+		}
+	}
+
+	shooterAt, shooterOnField := state.Locations[shot.Shooter]
+	for player := range state.Locations {
+		if slices.Contains(witnesses, player) {
+			continue
+		}
+		seen := shooterOnField && state.CanSeeCell(player, *shooterAt)
+		for _, cell := range shot.Path {
+			if seen {
+				break
+			}
+			seen = state.CanSeeCell(player, cell)
+		}
+		if seen {
+			witnesses = append(witnesses, player)
+		}
+	}
+	return witnesses
+}
+
+// VisiblePlayers returns the players the viewer can see, the viewer included
+func (state *GameState) VisiblePlayers(viewer string) []string {
+	visible := []string{}
+	for player := range state.Locations {
+		if state.CanSee(viewer, player) {
+			visible = append(visible, player)
+		}
+	}
+	return visible
+}
+
+// VisibleFlags returns the flags as the viewer sees them, every base is known
+// but a carried or dropped flag only shows up while its cell is in sight
+func (state *GameState) VisibleFlags(viewer string) map[string]FlagView {
+	flags := map[string]FlagView{}
+	for team, flag := range state.Flags {
+		view := FlagView{Base: flag.Base, Home: flag.isHome()}
+		if !view.Home && state.CanSeeCell(viewer, flag.Position) {
+			position := flag.Position
+			view.Position = &position
+			view.Carrier = flag.Carrier
+		}
+		flags[team] = view
+	}
+	return flags
+}
+
+// lineOfSight returns the cells strictly between the two positions
+// along a Bresenham line
+func lineOfSight(from Position, to Position) []Position {
+	cells := []Position{}
+	dx := abs(to.X - from.X)
+	dy := -abs(to.Y - from.Y)
+	stepX := sign(to.X - from.X)
+	stepY := sign(to.Y - from.Y)
+	err := dx + dy
+
+	x, y := from.X, from.Y
+	for {
+		doubled := 2 * err
+		if doubled >= dy {
+			err += dy
+			x += stepX
+		}
+		if doubled <= dx {
+			err += dx
+			y += stepY
+		}
+		if x == to.X && y == to.Y {
+			return cells
+		}
+		cells = append(cells, Position{X: x, Y: y})
+	}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+func sign(value int) int {
+	if value < 0 {
+		return -1
+	}
+	if value > 0 {
+		return 1
+	}
+	return 0
+}
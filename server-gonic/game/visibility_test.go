@@ -0,0 +1,122 @@
+package game
+
+import (
+	"sort"
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestShotWitnesses(t *testing.T) {
+	tests := []struct {
+		name      string
+		players   map[string]Position
+		witnesses []string
+	}{
+		{
+			name:      "players behind a wall don't see the shot",
+			players:   map[string]Position{"hidden": {0, 2}},
+			witnesses: []string{"shooter"},
+		},
+		{
+			name:      "players seeing the path see the shot",
+			players:   map[string]Position{"ahead": {9, 0}, "hidden": {9, 2}},
+			witnesses: []string{"ahead", "shooter"},
+		},
+		{
+			name:      "players seeing the shooter see the shot",
+			players:   map[string]Position{"behind": {0, 0}},
+			witnesses: []string{"behind", "shooter"},
+		},
+		{
+			name:      "the victim is told even when killed",
+			players:   map[string]Position{"victim": {4, 0}},
+			witnesses: []string{"shooter", "victim"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTestGame(t, []string{
+				"..........",
+				"##########",
+				"..........",
+			})
+			placePlayer(state, "shooter", Position{X: 1, Y: 0}, DefaultWeapon)
+			for playerId, at := range test.players {
+				player := placePlayer(state, playerId, at, DefaultWeapon)
+				player.Health = 1
+			}
+
+			shot, err := state.Shoot("shooter", Position{X: 1, Y: 0})
+			if err != nil {
+				t.Fatal(err)
+			}
+			witnesses := state.ShotWitnesses(shot)
+			sort.Strings(witnesses)
+			if !slices.Equal(witnesses, test.witnesses) {
+				t.Errorf("witnesses = %v, want %v", witnesses, test.witnesses)
+			}
+		})
+	}
+}
+
+func TestVisibleFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		viewerAt Position
+		flag     Flag
+		view     FlagView
+	}{
+		{
+			name:     "a flag at home is known",
+			viewerAt: Position{X: 0, Y: 2},
+			flag:     Flag{Base: Position{X: 0, Y: 0}, Position: Position{X: 0, Y: 0}},
+			view:     FlagView{Base: Position{X: 0, Y: 0}, Home: true},
+		},
+		{
+			name:     "a carried flag in sight shows its carrier",
+			viewerAt: Position{X: 0, Y: 0},
+			flag:     Flag{Base: Position{X: 0, Y: 0}, Position: Position{X: 5, Y: 0}, Carrier: "carrier"},
+			view: FlagView{
+				Base:     Position{X: 0, Y: 0},
+				Position: &Position{X: 5, Y: 0},
+				Carrier:  "carrier",
+			},
+		},
+		{
+			name:     "a carried flag behind a wall stays hidden",
+			viewerAt: Position{X: 0, Y: 2},
+			flag:     Flag{Base: Position{X: 0, Y: 0}, Position: Position{X: 5, Y: 0}, Carrier: "carrier"},
+			view:     FlagView{Base: Position{X: 0, Y: 0}},
+		},
+		{
+			name:     "a dropped flag behind a wall stays hidden",
+			viewerAt: Position{X: 0, Y: 2},
+			flag:     Flag{Base: Position{X: 0, Y: 0}, Position: Position{X: 5, Y: 0}},
+			view:     FlagView{Base: Position{X: 0, Y: 0}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTestGame(t, []string{
+				"..........",
+				"##########",
+				"..........",
+			})
+			placePlayer(state, "viewer", test.viewerAt, DefaultWeapon)
+			flag := test.flag
+			state.Flags["red"] = &flag
+
+			view := state.VisibleFlags("viewer")["red"]
+			if view.Base != test.view.Base || view.Home != test.view.Home || view.Carrier != test.view.Carrier {
+				t.Errorf("view = %+v, want %+v", view, test.view)
+			}
+			if (view.Position == nil) != (test.view.Position == nil) ||
+				view.Position != nil && *view.Position != *test.view.Position {
+				t.Errorf("position = %v, want %v", view.Position, test.view.Position)
+			}
+		})
+	}
+}
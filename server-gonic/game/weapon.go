@@ -0,0 +1,87 @@
+package game
+
+import "time"
+
+// Weapon holds the stats of a gun a player carries into the game.
+type Weapon struct {
+	Title        string        `json:"title"`
+	Damage       int           `json:"damage"`
+	Range        int           `json:"range"`
+	Cooldown     time.Duration `json:"cooldown"`
+	MagazineSize int           `json:"magazineSize"`
+	Spread       int           `json:"spread"`
+}
+
+// reserveMagazines is the number of spare magazines a player spawns with.
+const reserveMagazines = 3
+
+// ReloadDuration is how long a player can't fire after starting a reload.
+const ReloadDuration = 1500 * time.Millisecond
+
+// DefaultWeapon is given to players whose arsenal is empty.
+var DefaultWeapon = Weapon{
+	Title:        "Glock",
+	Damage:       20,
+	Range:        6,
+	Cooldown:     400 * time.Millisecond,
+	MagazineSize: 15,
+	Spread:       0,
+}
+
+// WeaponSlot is a weapon in a player's loadout together with its ammo.
+type WeaponSlot struct {
+	Weapon   Weapon `json:"weapon"`
+	Magazine int    `json:"magazine"`
+	Reserve  int    `json:"reserve"`
+}
+
+func newWeaponSlot(weapon Weapon) *WeaponSlot {
+	slot := &WeaponSlot{Weapon: weapon}
+	slot.refill()
+	return slot
+}
+
+func (slot *WeaponSlot) refill() {
+	slot.Magazine = slot.Weapon.MagazineSize
+	slot.Reserve = slot.Weapon.MagazineSize * reserveMagazines
+}
+
+// directions lists the eight possible shot directions clockwise,
+// so that neighbours in the slice are neighbours on the field as well.
+var directions = []Position{
+	{X: 0, Y: -1},
+	{X: 1, Y: -1},
+	{X: 1, Y: 0},
+	{X: 1, Y: 1},
+	{X: 0, Y: 1},
+	{X: -1, Y: 1},
+	{X: -1, Y: 0},
+	{X: -1, Y: -1},
+}
+
+// pelletDirections returns the directions of all pellets fired by the weapon:
+// the aimed one and Spread neighbours on either side of it.
+func (weapon Weapon) pelletDirections(aim Position) []Position {
+	aimIndex := -1
+	for i, direction := range directions {
+		if direction == aim {
+			aimIndex = i
+		}
+	}
+	if aimIndex == -1 {
+		return []Position{}
+	}
+
+	spread := weapon.Spread
+	if spread > len(directions)/2 {
+		spread = len(directions) / 2
+	}
+	pellets := []Position{aim}
+	for i := 1; i <= spread; i++ {
+		pellets = append(pellets, directions[(aimIndex+i)%len(directions)])
+		if 2*i < len(directions) {
+			pellets = append(pellets, directions[(aimIndex-i+len(directions))%len(directions)])
+		}
+	}
+	return pellets
+}
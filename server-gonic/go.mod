@@ -3,16 +3,19 @@ module shooter
 go 1.18
 
 require (
+	github.com/alicebob/miniredis/v2 v2.30.5
+	github.com/go-redis/redis/v8 v8.11.5
 	github.com/joho/godotenv v1.5.1
 	gorm.io/driver/postgres v1.5.2
 )
 
 require (
+	github.com/alicebob/gopher-json v0.0.0-20200520072559-a9ecdc9d1d3a // indirect
 	github.com/cespare/xxhash/v2 v2.1.2 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
-	github.com/go-redis/redis/v8 v8.11.5 // indirect
 	github.com/gookit/filter v1.1.4 // indirect
 	github.com/gookit/goutil v0.5.15 // indirect
+	github.com/yuin/gopher-lua v1.1.0 // indirect
 )
 
 require (
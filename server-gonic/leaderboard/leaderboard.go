@@ -0,0 +1,269 @@
+// Package leaderboard keeps live rankings of the players in Redis sorted sets.
+// Every stat is ranked in several windows: all time, the current week and
+// the current season.
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"shooter/models"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	Kills  = "kills"
+	Wins   = "wins"
+	Rating = "rating"
+
+	AllTime = "allTime"
+	Weekly  = "weekly"
+	Season  = "season"
+)
+
+var Stats = []string{Kills, Wins, Rating}
+var Windows = []string{AllTime, Weekly, Season}
+
+// Around is how many neighbours are listed on either side of a player's rank.
+const Around = 5
+
+// weeklyTTL keeps last week's sets around for a while after the week is over
+const weeklyTTL = 14 * 24 * time.Hour
+
+const namesKey = "leaderboard:names"
+
+var ErrUnknownStat = errors.New("unknown leaderboard stat")
+var ErrUnknownWindow = errors.New("unknown leaderboard window")
+var ErrNoSeason = errors.New("no season is running")
+
+// Entry is a player's place on a leaderboard, ranks start at 1
+type Entry struct {
+	Rank     int64   `json:"rank"`
+	UserID   uint    `json:"userId"`
+	Username string  `json:"username"`
+	Score    float64 `json:"score"`
+}
+
+// Result is what a player brings to the leaderboards from a finished match
+type Result struct {
+	UserID   uint
+	Username string
+	Kills    int
+	Won      bool
+	Rating   float64
+}
+
+type Leaderboards struct {
+	db *redis.Client
+
+	seasonMutex sync.RWMutex
+	season      string
+	seasonStart time.Time
+}
+
+func New(db *redis.Client) *Leaderboards {
+	return &Leaderboards{db: db}
+}
+
+// SetSeason makes the season with the given id and start date the one
+// played at the moment, an empty id means no season is running
+func (boards *Leaderboards) SetSeason(id string, start time.Time) {
+	boards.seasonMutex.Lock()
+	defer boards.seasonMutex.Unlock()
+	boards.season = id
+	boards.seasonStart = start
+}
+
+func (boards *Leaderboards) currentSeason() (string, time.Time) {
+	boards.seasonMutex.RLock()
+	defer boards.seasonMutex.RUnlock()
+	return boards.season, boards.seasonStart
+}
+
+// key is the sorted set of the stat in the window, windows that don't
+// apply at the moment have no key
+func (boards *Leaderboards) key(stat string, window string, now time.Time) (string, error) {
+	switch window {
+	case AllTime:
+		return "leaderboard:" + stat + ":allTime", nil
+	case Weekly:
+		year, week := now.ISOWeek()
+		return fmt.Sprintf("leaderboard:%s:week:%d-%02d", stat, year, week), nil
+	case Season:
+		season, _ := boards.currentSeason()
+		if season == "" {
+			return "", ErrNoSeason
+		}
+		return "leaderboard:" + stat + ":season:" + season, nil
+	}
+	return "", ErrUnknownWindow
+}
+
+// keys returns the sorted sets of the stat in every window that applies at the moment
+func (boards *Leaderboards) keys(stat string, now time.Time) []string {
+	keys := []string{}
+	for _, window := range Windows {
+		if key, err := boards.key(stat, window, now); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// RecordMatch adds the kills and wins of the players to every window
+// and sets their ratings to the new ones
+func (boards *Leaderboards) RecordMatch(results []Result) error {
+	ctx := context.Background()
+	now := time.Now()
+	_, err := boards.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, result := range results {
+			member := strconv.FormatUint(uint64(result.UserID), 10)
+			pipe.HSet(ctx, namesKey, member, result.Username)
+			for _, key := range boards.keys(Kills, now) {
+				pipe.ZIncrBy(ctx, key, float64(result.Kills), member)
+			}
+			for _, key := range boards.keys(Wins, now) {
+				if result.Won {
+					pipe.ZIncrBy(ctx, key, 1, member)
+				} else {
+					pipe.ZAddNX(ctx, key, &redis.Z{Score: 0, Member: member})
+				}
+			}
+			for _, key := range boards.keys(Rating, now) {
+				pipe.ZAdd(ctx, key, &redis.Z{Score: result.Rating, Member: member})
+			}
+		}
+		for _, stat := range Stats {
+			key, _ := boards.key(stat, Weekly, now)
+			pipe.Expire(ctx, key, weeklyTTL)
+		}
+		return nil
+	})
+	return err
+}
+
+// Top returns the best n players of the stat in the window
+func (boards *Leaderboards) Top(stat string, window string, n int64) ([]Entry, error) {
+	if !isStat(stat) {
+		return []Entry{}, ErrUnknownStat
+	}
+	key, err := boards.key(stat, window, time.Now())
+	if err != nil {
+		return []Entry{}, err
+	}
+	return boards.entries(key, 0, n-1)
+}
+
+// AroundUser returns the player's place together with the Around players
+// ranked right above and below, nothing if the player isn't ranked
+func (boards *Leaderboards) AroundUser(stat string, window string, userId uint) ([]Entry, error) {
+	if !isStat(stat) {
+		return []Entry{}, ErrUnknownStat
+	}
+	key, err := boards.key(stat, window, time.Now())
+	if err != nil {
+		return []Entry{}, err
+	}
+
+	rank, err := boards.db.ZRevRank(context.Background(), key, strconv.FormatUint(uint64(userId), 10)).Result()
+	if err == redis.Nil {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return []Entry{}, err
+	}
+	start := rank - Around
+	if start < 0 {
+		start = 0
+	}
+	return boards.entries(key, start, rank+Around)
+}
+
+func (boards *Leaderboards) entries(key string, start int64, stop int64) ([]Entry, error) {
+	ctx := context.Background()
+	ranked, err := boards.db.ZRevRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil {
+		return []Entry{}, err
+	}
+
+	entries := []Entry{}
+	for i, z := range ranked {
+		member, _ := z.Member.(string)
+		userId, _ := strconv.ParseUint(member, 10, 32)
+		username, _ := boards.db.HGet(ctx, namesKey, member).Result()
+		entries = append(entries, Entry{
+			Rank:     start + int64(i) + 1,
+			UserID:   uint(userId),
+			Username: username,
+			Score:    z.Score,
+		})
+	}
+	return entries, nil
+}
+
+// Rebuild replaces the sorted sets of every window with the totals stored in Postgres
+func (boards *Leaderboards) Rebuild() error {
+	now := time.Now()
+	year, month, day := now.Date()
+	weekStart := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	weekStart = weekStart.AddDate(0, 0, -(int(weekStart.Weekday())+6)%7)
+	_, seasonStart := boards.currentSeason()
+
+	since := map[string]time.Time{
+		AllTime: {},
+		Weekly:  weekStart,
+		Season:  seasonStart,
+	}
+	for _, window := range Windows {
+		if _, err := boards.key(Kills, window, now); err != nil {
+			continue
+		}
+		totals, err := models.GetPlayerTotals(since[window])
+		if err != nil {
+			return err
+		}
+		if err := boards.replace(window, totals, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (boards *Leaderboards) replace(window string, totals []models.PlayerTotals, now time.Time) error {
+	ctx := context.Background()
+	_, err := boards.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, stat := range Stats {
+			key, _ := boards.key(stat, window, now)
+			pipe.Del(ctx, key)
+			for _, total := range totals {
+				member := strconv.FormatUint(uint64(total.UserID), 10)
+				score := float64(total.Kills)
+				if stat == Wins {
+					score = float64(total.Wins)
+				} else if stat == Rating {
+					score = total.Rating
+				}
+				pipe.ZAdd(ctx, key, &redis.Z{Score: score, Member: member})
+				pipe.HSet(ctx, namesKey, member, total.Username)
+			}
+			if window == Weekly {
+				pipe.Expire(ctx, key, weeklyTTL)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+func isStat(stat string) bool {
+	for _, known := range Stats {
+		if known == stat {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"net/http"
+	jwt_token "shooter/utils/jwt"
+	"shooter/utils/revoked_tokens"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// JwtAuth lets through only requests carrying a valid token that hasn't been revoked
+func JwtAuth(db *redis.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenData, err := jwt_token.ExtractTokenData(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		revoked, err := revoked_tokens.IsRevoked(db, tokenData)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
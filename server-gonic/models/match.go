@@ -0,0 +1,109 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Match struct {
+	gorm.Model
+	Map          string             `gorm:"size:255;not null" json:"map"`
+	Mode         string             `gorm:"size:255;not null" json:"mode"`
+	StartedAt    time.Time          `json:"startedAt"`
+	EndedAt      time.Time          `gorm:"index" json:"endedAt"`
+	Duration     int                `gorm:"not null;default:0" json:"duration"` // seconds
+	SeasonID     *uint              `gorm:"index" json:"seasonId"`
+	Participants []MatchParticipant `json:"participants"`
+}
+
+// MatchParticipant is the result of one user in a finished match
+type MatchParticipant struct {
+	gorm.Model
+	MatchID     uint   `gorm:"not null;index" json:"matchId"`
+	UserID      uint   `gorm:"not null;index" json:"userId"`
+	User        User   `gorm:"foreignKey:UserID" json:"-"`
+	Team        string `gorm:"size:255" json:"team"`
+	Score       int    `gorm:"not null;default:0" json:"score"`
+	Kills       int    `gorm:"not null;default:0" json:"kills"`
+	Deaths      int    `gorm:"not null;default:0" json:"deaths"`
+	DamageDealt int    `gorm:"not null;default:0" json:"damageDealt"`
+	Weapon      string `gorm:"size:255" json:"weapon"`
+	Placement   int    `gorm:"not null" json:"placement"`
+	Won         bool   `gorm:"not null;default:false" json:"won"`
+}
+
+// PlayerTotals sums up the results of a user over many matches
+type PlayerTotals struct {
+	UserID   uint
+	Username string
+	Kills    int
+	Wins     int
+	Rating   float64
+}
+
+// SaveMatch stores the match together with its participants and updates
+// their ratings and season stats in the same transaction
+func (m *Match) SaveMatch() (*Match, error) {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+		if err := updateRatings(tx, m); err != nil {
+			return err
+		}
+		return addSeasonStats(tx, m)
+	})
+	if err != nil {
+		return &Match{}, err
+	}
+	return m, nil
+}
+
+// GetMatches returns finished matches, the latest first
+func GetMatches(limit int, offset int) ([]Match, error) {
+	var matches []Match
+	err := DB.Preload("Participants").
+		Order("ended_at desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&matches).Error
+	if err != nil {
+		return []Match{}, err
+	}
+	return matches, nil
+}
+
+// GetUserMatches returns the finished matches the user took part in, the latest first
+func GetUserMatches(userId uint, limit int, offset int) ([]Match, error) {
+	var matches []Match
+	err := DB.Preload("Participants").
+		Where("id IN (?)", DB.Model(&MatchParticipant{}).Select("match_id").Where("user_id = ?", userId)).
+		Order("ended_at desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&matches).Error
+	if err != nil {
+		return []Match{}, err
+	}
+	return matches, nil
+}
+
+// GetPlayerTotals sums up the kills and wins of every user in the matches
+// which ended since the given time, a zero time counts all matches
+func GetPlayerTotals(since time.Time) ([]PlayerTotals, error) {
+	var totals []PlayerTotals
+	query := DB.Model(&MatchParticipant{}).
+		Select("match_participants.user_id, users.username, SUM(match_participants.kills) AS kills, " +
+			"COUNT(*) FILTER (WHERE match_participants.won) AS wins, users.rating").
+		Joins("JOIN matches ON matches.id = match_participants.match_id").
+		Joins("JOIN users ON users.id = match_participants.user_id").
+		Group("match_participants.user_id, users.username, users.rating")
+	if !since.IsZero() {
+		query = query.Where("matches.ended_at >= ?", since)
+	}
+	if err := query.Scan(&totals).Error; err != nil {
+		return []PlayerTotals{}, err
+	}
+	return totals, nil
+}
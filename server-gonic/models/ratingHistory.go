@@ -0,0 +1,124 @@
+package models
+
+import (
+	"shooter/rating"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RatingHistory is the rating of a user right after a match
+type RatingHistory struct {
+	gorm.Model
+	UserID     uint    `gorm:"not null;index" json:"userId"`
+	User       User    `gorm:"foreignKey:UserID" json:"-"`
+	MatchID    uint    `gorm:"not null;index" json:"matchId"`
+	Match      Match   `gorm:"foreignKey:MatchID" json:"-"`
+	Rating     float64 `gorm:"not null" json:"rating"`
+	Deviation  float64 `gorm:"not null" json:"deviation"`
+	Volatility float64 `gorm:"not null" json:"volatility"`
+	Change     float64 `gorm:"not null;default:0" json:"change"`
+}
+
+type LeaderboardEntry struct {
+	Rank      int     `json:"rank"`
+	UserID    uint    `json:"userId"`
+	Username  string  `json:"username"`
+	Rating    float64 `json:"rating"`
+	Deviation float64 `json:"deviation"`
+}
+
+// updateRatings rates the participants of the match against each other
+// and records their new ratings. The users are locked until the
+// transaction is over, so concurrent matches don't overwrite each other.
+func updateRatings(tx *gorm.DB, match *Match) error {
+	if len(match.Participants) == 0 {
+		return nil
+	}
+	userIds := []uint{}
+	for _, participant := range match.Participants {
+		userIds = append(userIds, participant.UserID)
+	}
+	var users []User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "rating", "rating_deviation", "rating_volatility").
+		Find(&users, userIds).Error
+	if err != nil {
+		return err
+	}
+	usersById := map[uint]User{}
+	for _, user := range users {
+		usersById[user.ID] = user
+	}
+
+	rated := []User{}
+	participants := []rating.Participant{}
+	for _, participant := range match.Participants {
+		user, ok := usersById[participant.UserID]
+		if !ok {
+			continue
+		}
+		rated = append(rated, user)
+		participants = append(participants, rating.Participant{
+			Rating:    user.skillRating(),
+			Team:      participant.Team,
+			Placement: participant.Placement,
+		})
+	}
+
+	for i, updated := range rating.Match(participants) {
+		user := rated[i]
+		err := tx.Model(&user).UpdateColumns(map[string]interface{}{
+			"rating":            updated.Rating,
+			"rating_deviation":  updated.Deviation,
+			"rating_volatility": updated.Volatility,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Create(&RatingHistory{
+			UserID:     user.ID,
+			MatchID:    match.ID,
+			Rating:     updated.Rating,
+			Deviation:  updated.Deviation,
+			Volatility: updated.Volatility,
+			Change:     updated.Rating - user.Rating,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLeaderboard ranks the users by the last rating they reached within
+// the given window, a zero time leaves that side of the window open
+func GetLeaderboard(since time.Time, until time.Time, limit int, offset int) ([]LeaderboardEntry, error) {
+	latest := DB.Model(&RatingHistory{}).
+		Select("DISTINCT ON (user_id) user_id, rating, deviation").
+		Order("user_id, created_at desc")
+	if !since.IsZero() {
+		latest = latest.Where("created_at >= ?", since)
+	}
+	if !until.IsZero() {
+		latest = latest.Where("created_at < ?", until)
+	}
+
+	var entries []LeaderboardEntry
+	err := DB.Table("(?) AS latest", latest).
+		Select("latest.user_id, users.username, latest.rating, latest.deviation").
+		Joins("JOIN users ON users.id = latest.user_id").
+		Order("latest.rating desc").
+		Limit(limit).
+		Offset(offset).
+		Scan(&entries).Error
+	if err != nil {
+		return []LeaderboardEntry{}, err
+	}
+	for i := range entries {
+		entries[i].Rank = offset + i + 1
+	}
+	return entries, nil
+}
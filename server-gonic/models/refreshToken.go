@@ -0,0 +1,136 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	jwt_token "shooter/utils/jwt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RefreshTokenLifespan is how long a login lasts without being refreshed.
+var RefreshTokenLifespan = 30 * 24 * time.Hour
+
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// ErrRefreshTokenReused means a rotated refresh token was presented again,
+// the token was probably stolen and its whole family has been revoked
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// RefreshToken can be traded once for a new pair of tokens. Only the hash
+// of the token is stored. All tokens rotated from the same login share
+// the family.
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint       `gorm:"not null;index" json:"userId"`
+	User      User       `gorm:"foreignKey:UserID" json:"-"`
+	Family    string     `gorm:"size:36;not null;index" json:"family"`
+	TokenHash string     `gorm:"size:64;not null;unique" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"`
+}
+
+func hashRefreshToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// issueTokens creates an access token and a refresh token of the family
+func issueTokens(tx *gorm.DB, u User, family string) (TokenPair, error) {
+	accessToken, err := jwt_token.GenerateToken(u.ID, u.Username, family)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return TokenPair{}, err
+	}
+	refreshToken := hex.EncodeToString(random)
+	err = tx.Create(&RefreshToken{
+		UserID:    u.ID,
+		Family:    family,
+		TokenHash: hashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().Add(RefreshTokenLifespan),
+	}).Error
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(jwt_token.AccessTokenLifespan.Seconds()),
+	}, nil
+}
+
+// RotateRefreshToken trades the refresh token for a new pair of the same
+// family. A token that was already traded revokes the family, the family
+// is returned so the caller can revoke its access tokens as well.
+func RotateRefreshToken(token string) (TokenPair, string, error) {
+	var tokens TokenPair
+	var family string
+	reused := false
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var refreshToken RefreshToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("User").
+			Where("token_hash = ?", hashRefreshToken(token)).
+			First(&refreshToken).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+		family = refreshToken.Family
+
+		now := time.Now()
+		if refreshToken.RevokedAt != nil || now.After(refreshToken.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+		if refreshToken.UsedAt != nil {
+			// the revocation has to be committed, so it's not returned as an error
+			reused = true
+			return revokeFamily(tx, family, now)
+		}
+
+		err = tx.Model(&refreshToken).UpdateColumn("used_at", now).Error
+		if err != nil {
+			return err
+		}
+		tokens, err = issueTokens(tx, refreshToken.User, family)
+		return err
+	})
+	if err == nil && reused {
+		err = ErrRefreshTokenReused
+	}
+	return tokens, family, err
+}
+
+// RevokeTokenFamily ends the login the family was issued for
+func RevokeTokenFamily(family string) error {
+	return revokeFamily(DB, family, time.Now())
+}
+
+func revokeFamily(tx *gorm.DB, family string, now time.Time) error {
+	return tx.Model(&RefreshToken{}).
+		Where("family = ? AND revoked_at IS NULL", family).
+		UpdateColumn("revoked_at", now).Error
+}
+
+func newTokenFamily() string {
+	return uuid.NewString()
+}
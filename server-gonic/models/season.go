@@ -0,0 +1,233 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SeasonLength is how long a season started by a rollover lasts.
+var SeasonLength = 90 * 24 * time.Hour
+
+var ErrSeasonNotFound = errors.New("season not found")
+
+// Season is a period of play, stats of a season are frozen once it's archived
+type Season struct {
+	gorm.Model
+	Name       string     `gorm:"size:255;not null;unique" json:"name"`
+	StartsAt   time.Time  `gorm:"not null" json:"startsAt"`
+	EndsAt     time.Time  `gorm:"not null" json:"endsAt"`
+	ArchivedAt *time.Time `json:"archivedAt"`
+}
+
+// SeasonStats are the results of a user accumulated during a season
+type SeasonStats struct {
+	gorm.Model
+	SeasonID uint `gorm:"not null;uniqueIndex:idx_season_stats_season_user" json:"seasonId"`
+	UserID   uint `gorm:"not null;uniqueIndex:idx_season_stats_season_user" json:"userId"`
+	User     User `gorm:"foreignKey:UserID" json:"-"`
+	Games    int  `gorm:"not null;default:0" json:"games"`
+	Kills    int  `gorm:"not null;default:0" json:"kills"`
+	Wins     int  `gorm:"not null;default:0" json:"wins"`
+}
+
+// SeasonStanding is the final place of a user in an archived season
+type SeasonStanding struct {
+	gorm.Model
+	SeasonID uint    `gorm:"not null;uniqueIndex:idx_season_standings_season_user" json:"seasonId"`
+	UserID   uint    `gorm:"not null;uniqueIndex:idx_season_standings_season_user" json:"userId"`
+	User     User    `gorm:"foreignKey:UserID" json:"-"`
+	Rank     int     `gorm:"not null" json:"rank"`
+	Games    int     `gorm:"not null;default:0" json:"games"`
+	Kills    int     `gorm:"not null;default:0" json:"kills"`
+	Wins     int     `gorm:"not null;default:0" json:"wins"`
+	Rating   float64 `gorm:"not null" json:"rating"`
+}
+
+// CurrentSeason returns the season being played, the first season
+// is started when there is none yet
+func CurrentSeason() (Season, error) {
+	var season Season
+	err := DB.Where("archived_at IS NULL").Order("starts_at").First(&season).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		season = newSeason(1, time.Now())
+		err = DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&season).Error
+		if err != nil {
+			return Season{}, err
+		}
+		err = DB.Where("archived_at IS NULL").Order("starts_at").First(&season).Error
+	}
+	return season, err
+}
+
+func newSeason(number int64, startsAt time.Time) Season {
+	return Season{
+		Name:     fmt.Sprintf("Season %d", number),
+		StartsAt: startsAt,
+		EndsAt:   startsAt.Add(SeasonLength),
+	}
+}
+
+func GetSeasons() ([]Season, error) {
+	var seasons []Season
+	if err := DB.Order("starts_at desc").Find(&seasons).Error; err != nil {
+		return []Season{}, err
+	}
+	return seasons, nil
+}
+
+func GetSeason(seasonId uint) (Season, error) {
+	var season Season
+	err := DB.First(&season, seasonId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return season, ErrSeasonNotFound
+	}
+	return season, err
+}
+
+// addSeasonStats counts the match for its participants in the current season.
+// The season is share locked, so a rollover waits until the stats are in.
+func addSeasonStats(tx *gorm.DB, match *Match) error {
+	var season Season
+	err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("archived_at IS NULL").
+		Order("starts_at").
+		First(&season).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	match.SeasonID = &season.ID
+	if err := tx.Model(match).UpdateColumn("season_id", season.ID).Error; err != nil {
+		return err
+	}
+	for _, participant := range match.Participants {
+		wins := 0
+		if participant.Won {
+			wins = 1
+		}
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "season_id"}, {Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"games":      gorm.Expr("season_stats.games + 1"),
+				"kills":      gorm.Expr("season_stats.kills + ?", participant.Kills),
+				"wins":       gorm.Expr("season_stats.wins + ?", wins),
+				"updated_at": time.Now(),
+			}),
+		}).Create(&SeasonStats{
+			SeasonID: season.ID,
+			UserID:   participant.UserID,
+			Games:    1,
+			Kills:    participant.Kills,
+			Wins:     wins,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RolloverSeason archives the season and starts the next one. The standings
+// are frozen from the season stats and the ratings of the users. Rolling over
+// an archived season changes nothing, so the rollover is safe to repeat.
+func RolloverSeason(seasonId uint, now time.Time) (next Season, err error) {
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		var season Season
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&season, seasonId).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSeasonNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if season.ArchivedAt != nil {
+			return tx.Where("archived_at IS NULL").Order("starts_at").First(&next).Error
+		}
+
+		var stats []SeasonStats
+		err = tx.Preload("User").
+			Where("season_id = ?", season.ID).
+			Order("wins desc, kills desc, games asc").
+			Find(&stats).Error
+		if err != nil {
+			return err
+		}
+		for i, stat := range stats {
+			err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&SeasonStanding{
+				SeasonID: season.ID,
+				UserID:   stat.UserID,
+				Rank:     i + 1,
+				Games:    stat.Games,
+				Kills:    stat.Kills,
+				Wins:     stat.Wins,
+				Rating:   stat.User.Rating,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if now.Before(season.EndsAt) {
+			season.EndsAt = now
+		}
+		season.ArchivedAt = &now
+		if err := tx.Save(&season).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&Season{}).Count(&count).Error; err != nil {
+			return err
+		}
+		next = newSeason(count+1, season.EndsAt)
+		return tx.Create(&next).Error
+	})
+	return next, err
+}
+
+// GetSeasonStandings returns the final standings of an archived season,
+// or the live stats ranked the same way while the season is running
+func GetSeasonStandings(season Season, limit int, offset int) ([]SeasonStanding, error) {
+	standings := []SeasonStanding{}
+	if season.ArchivedAt != nil {
+		err := DB.Where("season_id = ?", season.ID).
+			Order("rank").
+			Limit(limit).
+			Offset(offset).
+			Find(&standings).Error
+		if err != nil {
+			return []SeasonStanding{}, err
+		}
+		return standings, nil
+	}
+
+	var stats []SeasonStats
+	err := DB.Preload("User").
+		Where("season_id = ?", season.ID).
+		Order("wins desc, kills desc, games asc").
+		Limit(limit).
+		Offset(offset).
+		Find(&stats).Error
+	if err != nil {
+		return []SeasonStanding{}, err
+	}
+	for i, stat := range stats {
+		standings = append(standings, SeasonStanding{
+			SeasonID: season.ID,
+			UserID:   stat.UserID,
+			Rank:     offset + i + 1,
+			Games:    stat.Games,
+			Kills:    stat.Kills,
+			Wins:     stat.Wins,
+			Rating:   stat.User.Rating,
+		})
+	}
+	return standings, nil
+}
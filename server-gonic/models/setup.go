@@ -39,4 +39,8 @@ func ConnectDataBase() {
 
 	DB.AutoMigrate(&User{})
 	DB.AutoMigrate(&Weapon{})
+	DB.AutoMigrate(&Match{}, &MatchParticipant{})
+	DB.AutoMigrate(&RatingHistory{})
+	DB.AutoMigrate(&Season{}, &SeasonStats{}, &SeasonStanding{})
+	DB.AutoMigrate(&RefreshToken{})
 }
@@ -3,7 +3,7 @@ package models
 import (
 	"fmt"
 	"html"
-	jwt_token "shooter/utils/jwt"
+	"shooter/rating"
 	"strings"
 
 	"golang.org/x/crypto/bcrypt"
@@ -12,9 +12,12 @@ import (
 
 type User struct {
 	gorm.Model
-	Username string   `gorm:"size:255;not null;unique" json:"username"`
-	Password string   `gorm:"size:255;not null;" json:"password"`
-	Weapons  []Weapon `gorm:"many2many:user_arsenal;"`
+	Username         string   `gorm:"size:255;not null;unique" json:"username"`
+	Password         string   `gorm:"size:255;not null;" json:"password"`
+	Weapons          []Weapon `gorm:"many2many:user_arsenal;"`
+	Rating           float64  `gorm:"not null;default:1500" json:"rating"`
+	RatingDeviation  float64  `gorm:"not null;default:350" json:"ratingDeviation"`
+	RatingVolatility float64  `gorm:"not null;default:0.06" json:"ratingVolatility"`
 }
 
 func (u *User) SaveUser() (*User, error) {
@@ -25,10 +28,24 @@ func (u *User) SaveUser() (*User, error) {
 	return u, nil
 }
 
+// GetUserRating returns the current skill rating of the user
+func GetUserRating(userId uint) (rating.Rating, error) {
+	u := User{}
+	err := DB.Model(User{}).Select("rating", "rating_deviation", "rating_volatility").Where("id = ?", userId).Take(&u).Error
+	if err != nil {
+		return rating.Default(), err
+	}
+	return u.skillRating(), nil
+}
+
+func (u *User) skillRating() rating.Rating {
+	return rating.Rating{Rating: u.Rating, Deviation: u.RatingDeviation, Volatility: u.RatingVolatility}
+}
+
 func VerifyPassword(password, hashedPassword string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
-func LoginCheck(username string, password string) (string, error) {
+func LoginCheck(username string, password string) (TokenPair, error) {
 
 	var err error
 
@@ -36,23 +53,28 @@ func LoginCheck(username string, password string) (string, error) {
 
 	err = DB.Model(User{}).Where("username = ?", username).Take(&u).Error
 	if err != nil {
-		return "", err
+		return TokenPair{}, err
 	}
 
 	err = VerifyPassword(password, u.Password)
 
 	if err != nil && err == bcrypt.ErrMismatchedHashAndPassword {
-		return "", err
+		return TokenPair{}, err
 	}
 
-	token, err := jwt_token.GenerateToken(u.ID, u.Username)
+	tokens, err := issueTokens(DB, u, newTokenFamily())
 	if err != nil {
 		fmt.Println(err)
-		return "", err
+		return TokenPair{}, err
 	}
 
-	return token, nil
+	return tokens, nil
+
+}
 
+// AfterCreate fills the arsenal of a new user with the starter weapons
+func (u *User) AfterCreate(tx *gorm.DB) error {
+	return GrantStarterWeapons(tx, u)
 }
 
 func (u *User) BeforeSave(*gorm.DB) error {
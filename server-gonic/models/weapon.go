@@ -10,4 +10,34 @@ type Weapon struct {
 	WeaponCategoryId int            `gorm:"not null;"`
 	WeaponCategory   WeaponCategory `gorm:"foreignKey:WeaponCategoryId"`
 	Users            []User         `gorm:"many2many:user_arsenal;"`
+	Damage           int            `gorm:"not null;default:10" json:"damage"`
+	Range            int            `gorm:"not null;default:5" json:"range"`
+	Cooldown         int            `gorm:"not null;default:500" json:"cooldown"` // milliseconds between shots
+	MagazineSize     int            `gorm:"not null;default:10" json:"magazineSize"`
+	Spread           int            `gorm:"not null;default:0" json:"spread"`
+}
+
+// StarterWeapons are the titles of the weapons every user's arsenal starts with
+var StarterWeapons = []string{"Glock", "Remington"}
+
+// GrantStarterWeapons adds the starter weapons to the user's arsenal
+func GrantStarterWeapons(db *gorm.DB, user *User) error {
+	var weapons []Weapon
+	if err := db.Where("title IN ?", StarterWeapons).Find(&weapons).Error; err != nil {
+		return err
+	}
+	if len(weapons) == 0 {
+		return nil
+	}
+	// appending saves the user, which mustn't hash its password again
+	return db.Session(&gorm.Session{SkipHooks: true}).Model(user).Association("Weapons").Append(weapons)
+}
+
+func GetUserArsenal(userId uint) ([]Weapon, error) {
+	var weapons []Weapon
+	err := DB.Model(&User{Model: gorm.Model{ID: userId}}).Association("Weapons").Find(&weapons)
+	if err != nil {
+		return []Weapon{}, err
+	}
+	return weapons, nil
 }
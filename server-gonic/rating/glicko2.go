@@ -0,0 +1,156 @@
+// Package rating implements the Glicko-2 rating system. Every finished
+// match is treated as one rating period in which each participant played
+// a game against every participant of another team.
+package rating
+
+import "math"
+
+const (
+	DefaultRating     = 1500.0
+	DefaultDeviation  = 350.0
+	DefaultVolatility = 0.06
+
+	// scale converts between the Glicko and the Glicko-2 scale
+	scale = 173.7178
+	// tau constrains the change of the volatility over time
+	tau = 0.5
+	// epsilon is the convergence tolerance of the volatility iteration
+	epsilon = 0.000001
+)
+
+// Rating is a player's skill estimate. Deviation is the uncertainty
+// of the estimate and Volatility how erratic the player's results are.
+type Rating struct {
+	Rating     float64 `json:"rating"`
+	Deviation  float64 `json:"deviation"`
+	Volatility float64 `json:"volatility"`
+}
+
+// Default is the rating of a player who hasn't played yet.
+func Default() Rating {
+	return Rating{Rating: DefaultRating, Deviation: DefaultDeviation, Volatility: DefaultVolatility}
+}
+
+// Result is the outcome of a single game against an opponent:
+// 1 for a win, 0.5 for a draw and 0 for a loss.
+type Result struct {
+	Opponent Rating
+	Score    float64
+}
+
+// Participant is a player in a finished match. Players of the same
+// non-empty team don't play against each other.
+type Participant struct {
+	Rating    Rating
+	Team      string
+	Placement int
+}
+
+// Match returns the new ratings of the participants in the same order.
+// A participant wins against everyone placed lower and draws with
+// everyone sharing their placement.
+func Match(participants []Participant) []Rating {
+	updated := make([]Rating, len(participants))
+	for i, player := range participants {
+		results := []Result{}
+		for j, opponent := range participants {
+			if i == j || (player.Team != "" && player.Team == opponent.Team) {
+				continue
+			}
+			score := 0.5
+			if player.Placement < opponent.Placement {
+				score = 1
+			} else if player.Placement > opponent.Placement {
+				score = 0
+			}
+			results = append(results, Result{Opponent: opponent.Rating, Score: score})
+		}
+		updated[i] = Update(player.Rating, results)
+	}
+	return updated
+}
+
+// Update applies the results of one rating period to the player's rating.
+// A player without results only becomes more uncertain.
+func Update(player Rating, results []Result) Rating {
+	mu := (player.Rating - DefaultRating) / scale
+	phi := player.Deviation / scale
+	sigma := player.Volatility
+
+	if len(results) == 0 {
+		return Rating{
+			Rating:     player.Rating,
+			Deviation:  math.Min(math.Sqrt(phi*phi+sigma*sigma)*scale, DefaultDeviation),
+			Volatility: sigma,
+		}
+	}
+
+	var inverseVariance, improvement float64
+	for _, result := range results {
+		opponentMu := (result.Opponent.Rating - DefaultRating) / scale
+		opponentG := g(result.Opponent.Deviation / scale)
+		e := expected(mu, opponentMu, opponentG)
+		inverseVariance += opponentG * opponentG * e * (1 - e)
+		improvement += opponentG * (result.Score - e)
+	}
+	variance := 1 / inverseVariance
+	delta := variance * improvement
+
+	newSigma := volatility(phi, sigma, variance, delta)
+	phiStar := math.Sqrt(phi*phi + newSigma*newSigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/variance)
+	newMu := mu + newPhi*newPhi*improvement
+
+	return Rating{
+		Rating:     newMu*scale + DefaultRating,
+		Deviation:  newPhi * scale,
+		Volatility: newSigma,
+	}
+}
+
+func g(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func expected(mu float64, opponentMu float64, opponentG float64) float64 {
+	return 1 / (1 + math.Exp(-opponentG*(mu-opponentMu)))
+}
+
+// volatility finds the new volatility with the Illinois algorithm
+// as described in the Glicko-2 paper
+func volatility(phi float64, sigma float64, variance float64, delta float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + variance + ex
+		return ex*(delta*delta-d)/(2*d*d) - (x-a)/(tau*tau)
+	}
+
+	lower := a
+	var upper float64
+	if delta*delta > phi*phi+variance {
+		upper = math.Log(delta*delta - phi*phi - variance)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		upper = a - k*tau
+	}
+
+	fLower := f(lower)
+	fUpper := f(upper)
+	for math.Abs(upper-lower) > epsilon {
+		c := lower + (lower-upper)*fLower/(fUpper-fLower)
+		fC := f(c)
+		if fC*fUpper <= 0 {
+			lower = upper
+			fLower = fUpper
+		} else {
+			fLower = fLower / 2
+		}
+		upper = c
+		fUpper = fC
+	}
+	return math.Exp(lower / 2)
+}
@@ -0,0 +1,130 @@
+package rating
+
+import (
+	"math"
+	"testing"
+)
+
+func assertClose(t *testing.T, name string, got float64, want float64, tolerance float64) {
+	t.Helper()
+	if math.Abs(got-want) > tolerance {
+		t.Errorf("%s = %.5f, want %.5f ± %g", name, got, want, tolerance)
+	}
+}
+
+// TestUpdatePaperExample is the worked example of Glickman's
+// "Example of the Glicko-2 system"
+func TestUpdatePaperExample(t *testing.T) {
+	player := Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+	results := []Result{
+		{Opponent: Rating{Rating: 1400, Deviation: 30, Volatility: DefaultVolatility}, Score: 1},
+		{Opponent: Rating{Rating: 1550, Deviation: 100, Volatility: DefaultVolatility}, Score: 0},
+		{Opponent: Rating{Rating: 1700, Deviation: 300, Volatility: DefaultVolatility}, Score: 0},
+	}
+
+	updated := Update(player, results)
+	assertClose(t, "rating", updated.Rating, 1464.06, 0.02)
+	assertClose(t, "deviation", updated.Deviation, 151.52, 0.01)
+	assertClose(t, "volatility", updated.Volatility, 0.05999, 0.00001)
+}
+
+func TestUpdateWithoutResults(t *testing.T) {
+	tests := []struct {
+		name      string
+		player    Rating
+		deviation float64
+	}{
+		{
+			name:      "deviation grows with the volatility",
+			player:    Rating{Rating: 1500, Deviation: 50, Volatility: 0.06},
+			deviation: math.Sqrt(50*50 + (0.06*scale)*(0.06*scale)),
+		},
+		{
+			name:      "deviation is capped at the default",
+			player:    Default(),
+			deviation: DefaultDeviation,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			updated := Update(test.player, []Result{})
+			assertClose(t, "rating", updated.Rating, test.player.Rating, 0)
+			assertClose(t, "deviation", updated.Deviation, test.deviation, 0.000001)
+			assertClose(t, "volatility", updated.Volatility, test.player.Volatility, 0)
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name         string
+		participants []Participant
+		// change is the sign of every participant's rating change
+		change []int
+	}{
+		{
+			name: "the better placed player gains",
+			participants: []Participant{
+				{Rating: Default(), Placement: 1},
+				{Rating: Default(), Placement: 2},
+			},
+			change: []int{1, -1},
+		},
+		{
+			name: "equal players sharing a placement keep their rating",
+			participants: []Participant{
+				{Rating: Default(), Placement: 1},
+				{Rating: Default(), Placement: 1},
+			},
+			change: []int{0, 0},
+		},
+		{
+			name: "teammates aren't rated against each other",
+			participants: []Participant{
+				{Rating: Default(), Team: "red", Placement: 1},
+				{Rating: Rating{Rating: 1800, Deviation: 100, Volatility: 0.06}, Team: "red", Placement: 1},
+				{Rating: Default(), Team: "blue", Placement: 2},
+			},
+			change: []int{1, 1, -1},
+		},
+		{
+			name: "a favourite placed last loses",
+			participants: []Participant{
+				{Rating: Rating{Rating: 1900, Deviation: 80, Volatility: 0.06}, Placement: 3},
+				{Rating: Default(), Placement: 1},
+				{Rating: Default(), Placement: 2},
+			},
+			change: []int{-1, 1, 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			updated := Match(test.participants)
+			for i, rating := range updated {
+				change := rating.Rating - test.participants[i].Rating.Rating
+				sign := 0
+				if change > 0.000001 {
+					sign = 1
+				} else if change < -0.000001 {
+					sign = -1
+				}
+				if sign != test.change[i] {
+					t.Errorf("change of participant %d = %.3f, want sign %d", i, change, test.change[i])
+				}
+				if rating.Deviation >= test.participants[i].Rating.Deviation {
+					t.Errorf("deviation of participant %d grew from %.3f to %.3f",
+						i, test.participants[i].Rating.Deviation, rating.Deviation)
+				}
+			}
+
+			again := Match(test.participants)
+			for i := range updated {
+				if updated[i] != again[i] {
+					t.Errorf("participant %d rated %v, then %v", i, updated[i], again[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Package seasons rolls the current season over once it has ended and keeps
+// the live leaderboards pointed at the season being played.
+package seasons
+
+import (
+	"log"
+	"shooter/leaderboard"
+	"shooter/models"
+	"strconv"
+	"time"
+)
+
+// CheckInterval is how often the scheduled job looks for an ended season.
+var CheckInterval = time.Hour
+
+// Start makes sure a season is running and hands it over to the leaderboards
+func Start(leaderboards *leaderboard.Leaderboards) error {
+	season, err := models.CurrentSeason()
+	if err != nil {
+		return err
+	}
+	setLeaderboardSeason(leaderboards, season)
+	return nil
+}
+
+// Schedule rolls the current season over whenever its end date has passed
+// and picks up rollovers done by other server processes. It blocks, so it's
+// meant to be run in its own goroutine.
+func Schedule(leaderboards *leaderboard.Leaderboards) {
+	ticker := time.NewTicker(CheckInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		season, err := models.CurrentSeason()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		if now.Before(season.EndsAt) {
+			setLeaderboardSeason(leaderboards, season)
+			continue
+		}
+		if _, err := Rollover(leaderboards, season.ID); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// Rollover archives the season and moves the leaderboards on to the next one.
+// Rolling over a season which is already archived only returns the running one.
+func Rollover(leaderboards *leaderboard.Leaderboards, seasonId uint) (models.Season, error) {
+	next, err := models.RolloverSeason(seasonId, time.Now())
+	if err != nil {
+		return next, err
+	}
+	setLeaderboardSeason(leaderboards, next)
+	return next, nil
+}
+
+func setLeaderboardSeason(leaderboards *leaderboard.Leaderboards, season models.Season) {
+	leaderboards.SetSeason(strconv.FormatUint(uint64(season.ID), 10), season.StartsAt)
+}
@@ -7,23 +7,36 @@ import (
 func Seed() {
 	weaponCategories := []models.WeaponCategory{
 		{Title: "shotguns"},
+		{Title: "pistols"},
+		{Title: "rifles"},
 	}
-	weapons := []models.Weapon{
-		{Title: "Remington", WeaponCategoryId: 1},
+	weapons := map[string][]models.Weapon{
+		"shotguns": {
+			{Title: "Remington", Damage: 35, Range: 3, Cooldown: 900, MagazineSize: 6, Spread: 1},
+		},
+		"pistols": {
+			{Title: "Glock", Damage: 20, Range: 6, Cooldown: 400, MagazineSize: 15, Spread: 0},
+		},
+		"rifles": {
+			{Title: "AK-47", Damage: 30, Range: 9, Cooldown: 150, MagazineSize: 30, Spread: 0},
+		},
 	}
 
 	transaction := models.DB.Begin()
 
-	queryObject := map[string]interface{}{}
-	queryResult := models.DB.Model(models.WeaponCategory{}).Limit(1).Find(&queryObject)
-
-	if queryResult.RowsAffected == 0 {
-		transaction.Model(models.WeaponCategory{}).Create(weaponCategories)
+	for _, category := range weaponCategories {
+		transaction.Where(models.WeaponCategory{Title: category.Title}).FirstOrCreate(&category)
+		for _, weapon := range weapons[category.Title] {
+			weapon.WeaponCategoryId = int(category.ID)
+			transaction.Where(models.Weapon{Title: weapon.Title}).Assign(weapon).FirstOrCreate(&models.Weapon{})
+		}
 	}
 
-	queryResult = transaction.Model(models.Weapon{}).Limit(1).Find(&queryObject)
-	if queryResult.RowsAffected == 0 {
-		transaction.Model(models.Weapon{}).Create(weapons)
+	// users registered before the starter weapons existed get them now
+	var unarmed []models.User
+	transaction.Where("NOT EXISTS (SELECT 1 FROM user_arsenal WHERE user_arsenal.user_id = users.id)").Find(&unarmed)
+	for _, user := range unarmed {
+		models.GrantStarterWeapons(transaction, &user)
 	}
 	transaction.Commit()
 }
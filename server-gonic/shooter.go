@@ -1,15 +1,23 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"log"
 	"os"
 	"path"
 	"shooter/controllers"
+	"shooter/game"
+	"shooter/leaderboard"
+	"shooter/middlewares"
 	"shooter/models"
+	"shooter/seasons"
 	seeding "shooter/seeders"
 	"shooter/socket"
+	"shooter/store"
+	jwt_token "shooter/utils/jwt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -18,7 +26,6 @@ import (
 )
 
 func main() {
-	ctx := context.Background()
 	err := godotenv.Load(".env")
 
 	if err != nil {
@@ -32,6 +39,19 @@ func main() {
 	r := gin.Default()
 	r.LoadHTMLGlob(path.Join(dir, "./templates/*.*"))
 
+	if accessTokenMinutes, err := strconv.Atoi(os.Getenv("ACCESS_TOKEN_MINUTES")); err == nil && accessTokenMinutes > 0 {
+		jwt_token.AccessTokenLifespan = time.Duration(accessTokenMinutes) * time.Minute
+	}
+	if refreshTokenDays, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_DAYS")); err == nil && refreshTokenDays > 0 {
+		models.RefreshTokenLifespan = time.Duration(refreshTokenDays) * 24 * time.Hour
+	}
+	if os.Getenv("QUERY_TOKEN_ENABLED") == "false" {
+		jwt_token.AllowQueryToken = false
+	}
+	if origins := os.Getenv("WS_ALLOWED_ORIGINS"); origins != "" {
+		controllers.AllowedOrigins = strings.Split(origins, ",")
+	}
+
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = []string{"http://localhost:3001"}
 	r.Use(cors.New(corsConfig))
@@ -44,8 +64,84 @@ func main() {
 	if redisClient == nil {
 		log.Fatalf("Error connecting Redis")
 	}
-	redisClient.FlushAll(ctx)
-	hub := socket.NewHub(*redisClient)
+
+	mapsDir := os.Getenv("MAPS_DIR")
+	if mapsDir == "" {
+		mapsDir = "maps"
+	}
+	if err := game.LoadMaps(path.Join(dir, mapsDir)); err != nil {
+		log.Fatalf("Error loading maps: %v", err)
+	}
+
+	if respawnDelay, err := strconv.Atoi(os.Getenv("RESPAWN_DELAY_SECONDS")); err == nil {
+		game.DefaultRespawnDelay = time.Duration(respawnDelay) * time.Second
+	}
+	if minPlayers, err := strconv.Atoi(os.Getenv("MIN_PLAYERS")); err == nil && minPlayers > 0 {
+		game.MinPlayers = minPlayers
+	}
+	if matchDuration, err := strconv.Atoi(os.Getenv("MATCH_DURATION_SECONDS")); err == nil && matchDuration > 0 {
+		game.MatchDuration = time.Duration(matchDuration) * time.Second
+	}
+	if botFill, err := strconv.Atoi(os.Getenv("BOT_FILL")); err == nil && botFill >= 0 {
+		socket.BotFill = botFill
+	}
+	if botDifficulty := os.Getenv("BOT_DIFFICULTY"); game.HasBotDifficulty(botDifficulty) {
+		socket.DefaultBotDifficulty = botDifficulty
+	}
+	if grace, err := strconv.Atoi(os.Getenv("RESUME_GRACE_SECONDS")); err == nil && grace >= 0 {
+		socket.ResumeGracePeriod = time.Duration(grace) * time.Second
+	}
+	if policy := os.Getenv("DUPLICATE_LOGIN_POLICY"); policy != "" {
+		if !socket.IsDuplicateLoginPolicy(policy) {
+			log.Fatalf("DUPLICATE_LOGIN_POLICY must be one of %q, %q and %q, got %q",
+				socket.RejectDuplicate, socket.KickOld, socket.AllowMultiDevice, policy)
+		}
+		socket.DuplicateLoginPolicy = policy
+	}
+	if tickRate, err := strconv.Atoi(os.Getenv("TICK_RATE")); err == nil && tickRate > 0 {
+		socket.TickRate = tickRate
+	}
+	if matchSize, err := strconv.Atoi(os.Getenv("MATCH_SIZE")); err == nil {
+		if matchSize < 2 {
+			log.Fatalf("MATCH_SIZE must be at least 2, got %d", matchSize)
+		}
+		socket.MatchSize = matchSize
+	}
+	if ratingWindow, err := strconv.Atoi(os.Getenv("QUEUE_RATING_WINDOW")); err == nil && ratingWindow > 0 {
+		socket.QueueRatingWindow = float64(ratingWindow)
+	}
+	if widenAfter, err := strconv.Atoi(os.Getenv("QUEUE_WIDEN_SECONDS")); err == nil {
+		if widenAfter <= 0 {
+			log.Fatalf("QUEUE_WIDEN_SECONDS must be positive, got %d", widenAfter)
+		}
+		socket.QueueWidenAfter = time.Duration(widenAfter) * time.Second
+	}
+	if timeout, err := strconv.Atoi(os.Getenv("QUEUE_TIMEOUT_SECONDS")); err == nil {
+		if timeout <= 0 {
+			log.Fatalf("QUEUE_TIMEOUT_SECONDS must be positive, got %d", timeout)
+		}
+		socket.QueueTimeout = time.Duration(timeout) * time.Second
+	}
+	if emptyRoomTimeout, err := strconv.Atoi(os.Getenv("EMPTY_ROOM_TIMEOUT_SECONDS")); err == nil && emptyRoomTimeout > 0 {
+		socket.EmptyRoomTimeout = time.Duration(emptyRoomTimeout) * time.Second
+	}
+	var games store.GameStore = store.NewRedisGameStore(redisClient)
+	var rooms store.RoomStore = store.NewRedisRoomStore(redisClient)
+	var queue store.QueueStore = store.NewRedisQueueStore(redisClient)
+	if os.Getenv("GAME_STORE") == "memory" {
+		games = store.NewMemoryGameStore()
+		rooms = store.NewMemoryRoomStore()
+		queue = store.NewMemoryQueueStore()
+	}
+	if seasonLength, err := strconv.Atoi(os.Getenv("SEASON_LENGTH_DAYS")); err == nil && seasonLength > 0 {
+		models.SeasonLength = time.Duration(seasonLength) * 24 * time.Hour
+	}
+	leaderboards := leaderboard.New(redisClient)
+	if err := seasons.Start(leaderboards); err != nil {
+		log.Fatalf("Error starting season: %v", err)
+	}
+	go seasons.Schedule(leaderboards)
+	hub := socket.NewHub(games, rooms, queue, leaderboards)
 	go hub.Run()
 
 	r.GET("/", func(c *gin.Context) {
@@ -53,13 +149,43 @@ func main() {
 	})
 
 	r.GET("/ws", func(c *gin.Context) {
-		controllers.WS(c, hub)
+		controllers.WS(c, hub, redisClient)
 	})
 
 	public := r.Group("/api")
 
 	public.POST("/register", controllers.Register)
 	public.POST("/login", controllers.Login)
+	public.POST("/refresh", func(c *gin.Context) {
+		controllers.Refresh(c, redisClient, hub)
+	})
+	public.POST("/logout", middlewares.JwtAuth(redisClient), func(c *gin.Context) {
+		controllers.Logout(c, redisClient, hub)
+	})
+	public.POST("/ws-ticket", middlewares.JwtAuth(redisClient), func(c *gin.Context) {
+		controllers.WSTicket(c, redisClient)
+	})
+	public.GET("/matches", controllers.Matches)
+	public.GET("/users/:id/matches", controllers.UserMatches)
+	public.GET("/leaderboard", controllers.Leaderboard)
+	public.GET("/leaderboards/:stat", func(c *gin.Context) {
+		controllers.StatLeaderboard(c, leaderboards)
+	})
+	public.GET("/leaderboards/:stat/around-me", middlewares.JwtAuth(redisClient), func(c *gin.Context) {
+		controllers.StatLeaderboardAroundMe(c, leaderboards)
+	})
+	public.GET("/seasons", controllers.Seasons)
+	public.GET("/seasons/:id/standings", controllers.SeasonStandings)
+
+	admin := r.Group("/api/admin")
+	admin.Use(middlewares.AdminAuth())
+
+	admin.POST("/leaderboards/rebuild", func(c *gin.Context) {
+		controllers.RebuildStatLeaderboards(c, leaderboards)
+	})
+	admin.POST("/seasons/:id/rollover", func(c *gin.Context) {
+		controllers.RolloverSeason(c, leaderboards)
+	})
 
 	r.Run(fmt.Sprintf("localhost:%s", port))
 }
@@ -0,0 +1,32 @@
+package socket
+
+import (
+	"log"
+	"shooter/game"
+	"shooter/models"
+	"time"
+)
+
+// getUserArsenal reads the arsenal of a user, tests swap it for a fake database
+var getUserArsenal = models.GetUserArsenal
+
+// loadUserLoadout turns the user's arsenal from the database into the in-game weapons
+func loadUserLoadout(userId int) []game.Weapon {
+	arsenal, err := getUserArsenal(uint(userId))
+	if err != nil {
+		log.Println(err)
+	}
+
+	loadout := []game.Weapon{}
+	for _, weapon := range arsenal {
+		loadout = append(loadout, game.Weapon{
+			Title:        weapon.Title,
+			Damage:       weapon.Damage,
+			Range:        weapon.Range,
+			Cooldown:     time.Duration(weapon.Cooldown) * time.Millisecond,
+			MagazineSize: weapon.MagazineSize,
+			Spread:       weapon.Spread,
+		})
+	}
+	return loadout
+}
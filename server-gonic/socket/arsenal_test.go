@@ -0,0 +1,79 @@
+package socket
+
+import (
+	"shooter/game"
+	"shooter/models"
+	"testing"
+	"time"
+)
+
+// fakeArsenal gives every user the weapons for the rest of the test
+func fakeArsenal(t *testing.T, weapons []models.Weapon) {
+	getUserArsenal = func(userId uint) ([]models.Weapon, error) {
+		return weapons, nil
+	}
+	t.Cleanup(func() { getUserArsenal = models.GetUserArsenal })
+}
+
+func TestJoinGameWithArsenal(t *testing.T) {
+	tests := []struct {
+		name    string
+		arsenal []models.Weapon
+		weapon  game.Weapon
+	}{
+		{
+			name:    "a user without weapons gets the default one",
+			arsenal: []models.Weapon{},
+			weapon:  game.DefaultWeapon,
+		},
+		{
+			name: "a user spawns with the weapon of its arsenal",
+			arsenal: []models.Weapon{
+				{Title: "AK-47", Damage: 30, Range: 9, Cooldown: 150, MagazineSize: 30},
+			},
+			weapon: game.Weapon{
+				Title:        "AK-47",
+				Damage:       30,
+				Range:        9,
+				Cooldown:     150 * time.Millisecond,
+				MagazineSize: 30,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fakeArsenal(t, test.arsenal)
+			hub := newTestHub()
+			client := newTestClient(hub, 1)
+
+			handleSocketPayloadEvents(client, SocketEventStruct{
+				EventName:    "joinGame",
+				EventPayload: map[string]interface{}{},
+			})
+			t.Cleanup(func() { leaveGame(client) })
+
+			hubGame, err := hub.games.Load(defaultRoomId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			player, ok := hubGame.Players[client.clientId]
+			if !ok {
+				t.Fatal("the player didn't join")
+			}
+			if len(player.Loadout) != 1 || player.Loadout[player.ActiveWeapon].Weapon != test.weapon {
+				t.Errorf("loadout = %+v, want only %+v", player.Loadout, test.weapon)
+			}
+
+			// the client is told which weapon it holds
+			loadout, _ := sentEvent(t, client, "gameState")["loadout"].([]interface{})
+			if len(loadout) != 1 {
+				t.Fatalf("sent loadout = %v, want a single weapon", loadout)
+			}
+			weapon, _ := loadout[0].(map[string]interface{})["weapon"].(map[string]interface{})
+			if weapon["title"] != test.weapon.Title {
+				t.Errorf("sent weapon = %v, want %s", weapon["title"], test.weapon.Title)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package socket
+
+import (
+	"shooter/game"
+	"time"
+)
+
+// BotFill is the number of players a room with humans in it is filled up to with bots
+var BotFill = 0
+
+// DefaultBotDifficulty is used for rooms created without a bot difficulty
+var DefaultBotDifficulty = game.DefaultBotDifficulty
+
+// runBots fills the empty slots of the game with bots, or makes room
+// for humans, and applies the inputs of every bot for this tick
+func runBots(gameState *game.GameState, moved map[string]bool, outbox *tickOutbox) {
+	added, removed := gameState.FillWithBots(BotFill)
+	if len(added) > 0 || len(removed) > 0 {
+		joining := []UserGameLocation{}
+		for _, botId := range added {
+			joining = append(joining, UserGameLocation{
+				User:   botUser(gameState, botId),
+				Health: gameState.Players[botId].Health,
+				Team:   gameState.Players[botId].Team,
+			})
+		}
+		disconnecting := []UserStruct{}
+		for _, botId := range removed {
+			disconnecting = append(disconnecting, UserStruct{ClientID: botId})
+		}
+		outbox.roomEvents = append(outbox.roomEvents, SocketEventStruct{
+			EventName: "joinGame",
+			EventPayload: structToEventPayload(JoinDisconnectGameCommonPayload{
+				Joining:       joining,
+				Disconnecting: disconnecting,
+			}),
+		})
+	}
+
+	now := time.Now()
+	for playerId := range gameState.Players {
+		if !game.IsBot(playerId) {
+			continue
+		}
+		for _, action := range gameState.BotActions(playerId, now) {
+			applyInput(gameState, playerInput{
+				clientId: playerId,
+				event: SocketEventStruct{
+					EventName: action.Name,
+					EventPayload: map[string]interface{}{
+						"x": float64(action.Direction.X),
+						"y": float64(action.Direction.Y),
+					},
+				},
+			}, moved, outbox)
+		}
+	}
+}
+
+func botUser(gameState *game.GameState, botId string) UserStruct {
+	user := UserStruct{ClientID: botId}
+	if player, ok := gameState.Players[botId]; ok && player.Bot != nil {
+		user.UserName = player.Bot.Name
+	}
+	return user
+}
+
+// getGameUser returns the user behind a player of the game, bots included
+func getGameUser(hub *Hub, gameState *game.GameState, clientId string) UserStruct {
+	if game.IsBot(clientId) {
+		return botUser(gameState, clientId)
+	}
+	return getUserByClientID(hub, clientId)
+}
@@ -0,0 +1,58 @@
+package socket
+
+// What happens when a user connects while already connected
+const (
+	// RejectDuplicate keeps the older connection and closes the new one
+	RejectDuplicate = "reject"
+	// KickOld closes the older connection, the new one takes over its player
+	KickOld = "kickOld"
+	// AllowMultiDevice lets the user play on every connection
+	AllowMultiDevice = "multiDevice"
+)
+
+// DuplicateLoginPolicy is one of RejectDuplicate, KickOld and AllowMultiDevice
+var DuplicateLoginPolicy = RejectDuplicate
+
+// IsDuplicateLoginPolicy reports whether policy is one of the known policies
+func IsDuplicateLoginPolicy(policy string) bool {
+	switch policy {
+	case RejectDuplicate, KickOld, AllowMultiDevice:
+		return true
+	}
+	return false
+}
+
+func connectedClientOfUser(hub *Hub, userId int) *Client {
+	for _, connected := range hub.connectedClients() {
+		if connected.userID == userId {
+			return connected
+		}
+	}
+	return nil
+}
+
+// rejectLogin tells the new connection why it's refused and closes it. The
+// client was never registered, so the event is handed to its pump directly.
+func rejectLogin(client *Client) {
+	client.send <- SocketEventStruct{
+		EventName:    "loginRejected",
+		EventPayload: map[string]interface{}{"reason": "alreadyConnected"},
+	}
+	close(client.send)
+}
+
+// replaceSession tells the older connection it was replaced and lets the new
+// one resume its session. It reports false when there was no session to take
+// over, the older connection is closed anyway.
+func replaceSession(hub *Hub, client *Client, previous *Client) bool {
+	hub.sendToClient(previous, SocketEventStruct{
+		EventName:    "sessionReplaced",
+		EventPayload: map[string]interface{}{"clientId": previous.clientId},
+	})
+	client.resumeToken = previous.resumeToken
+	if resumeSession(hub, client) {
+		return true
+	}
+	hub.removeClient(previous)
+	return false
+}
@@ -0,0 +1,186 @@
+package socket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"shooter/store"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// newTestHub returns a hub on memory stores, its goroutine isn't started
+func newTestHub() *Hub {
+	return NewHub(store.NewMemoryGameStore(), store.NewMemoryRoomStore(), store.NewMemoryQueueStore(), nil)
+}
+
+// newTestClient registers a client without a connection,
+// the events sent to it pile up in its send buffer
+func newTestClient(hub *Hub, userId int) *Client {
+	client := &Client{
+		hub:      hub,
+		send:     make(chan SocketEventStruct, sendBufferSize),
+		stopped:  make(chan struct{}),
+		clientId: uuid.New().String(),
+		userID:   userId,
+		userName: "player" + strconv.Itoa(userId),
+	}
+	hub.clientsMutex.Lock()
+	hub.clients[client] = true
+	hub.clientsMutex.Unlock()
+	return client
+}
+
+// sentEvent skips the events sent to the client until eventName comes up
+func sentEvent(t *testing.T, client *Client, eventName string) map[string]interface{} {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case event, ok := <-client.send:
+			if !ok {
+				t.Fatalf("waiting for %s: client removed", eventName)
+			}
+			if event.EventName == eventName {
+				return event.EventPayload
+			}
+		case <-timeout:
+			t.Fatalf("waiting for %s: timed out", eventName)
+		}
+	}
+}
+
+// newTestServer runs a hub on memory stores behind a websocket endpoint
+// that logs every connection in as the same user, the token family is
+// taken from the query
+func newTestServer(t *testing.T) (*Hub, string) {
+	t.Helper()
+	hub := newTestHub()
+	go hub.Run()
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connection, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		CreateNewSocketUser(hub, connection, 1, "player", r.URL.Query().Get("family"), "")
+	}))
+	t.Cleanup(server.Close)
+	return hub, "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func dial(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	connection, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { connection.Close() })
+	return connection
+}
+
+// readEvent skips events until eventName arrives
+func readEvent(t *testing.T, connection *websocket.Conn, eventName string) map[string]interface{} {
+	t.Helper()
+	connection.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var event SocketEventStruct
+		if err := connection.ReadJSON(&event); err != nil {
+			t.Fatalf("waiting for %s: %v", eventName, err)
+		}
+		if event.EventName == eventName {
+			return event.EventPayload
+		}
+	}
+}
+
+func assertClosed(t *testing.T, connection *websocket.Conn) {
+	t.Helper()
+	connection.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := connection.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNoStatusReceived) {
+				t.Errorf("connection ended with %v, want it closed", err)
+			}
+			return
+		}
+	}
+}
+
+func connectionsOfUser(hub *Hub, userId int) int {
+	count := 0
+	for _, client := range hub.connectedClients() {
+		if client.userID == userId {
+			count++
+		}
+	}
+	return count
+}
+
+func TestDuplicateLogin(t *testing.T) {
+	defaultPolicy := DuplicateLoginPolicy
+	t.Cleanup(func() { DuplicateLoginPolicy = defaultPolicy })
+
+	t.Run("reject", func(t *testing.T) {
+		DuplicateLoginPolicy = RejectDuplicate
+		hub, url := newTestServer(t)
+
+		first := dial(t, url)
+		readEvent(t, first, "session")
+		second := dial(t, url)
+
+		payload := readEvent(t, second, "loginRejected")
+		if payload["reason"] != "alreadyConnected" {
+			t.Errorf("reason = %v, want alreadyConnected", payload["reason"])
+		}
+		assertClosed(t, second)
+		if count := connectionsOfUser(hub, 1); count != 1 {
+			t.Errorf("connections = %d, want 1", count)
+		}
+	})
+
+	t.Run("kickOld", func(t *testing.T) {
+		DuplicateLoginPolicy = KickOld
+		hub, url := newTestServer(t)
+
+		first := dial(t, url)
+		started := readEvent(t, first, "session")
+		second := dial(t, url)
+
+		readEvent(t, first, "sessionReplaced")
+		assertClosed(t, first)
+		resumed := readEvent(t, second, "session")
+		if resumed["resumed"] != true {
+			t.Errorf("resumed = %v, want true", resumed["resumed"])
+		}
+		if resumed["clientId"] != started["clientId"] {
+			t.Errorf("clientId = %v, want the replaced %v", resumed["clientId"], started["clientId"])
+		}
+		if count := connectionsOfUser(hub, 1); count != 1 {
+			t.Errorf("connections = %d, want 1", count)
+		}
+	})
+
+	t.Run("multiDevice", func(t *testing.T) {
+		DuplicateLoginPolicy = AllowMultiDevice
+		hub, url := newTestServer(t)
+
+		first := dial(t, url)
+		firstSession := readEvent(t, first, "session")
+		second := dial(t, url)
+		secondSession := readEvent(t, second, "session")
+
+		if firstSession["clientId"] == secondSession["clientId"] {
+			t.Errorf("both connections got client %v", firstSession["clientId"])
+		}
+		if count := connectionsOfUser(hub, 1); count != 2 {
+			t.Errorf("connections = %d, want 2", count)
+		}
+	})
+}
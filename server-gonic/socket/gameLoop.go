@@ -0,0 +1,403 @@
+package socket
+
+import (
+	"errors"
+	"log"
+	"shooter/game"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// TickRate is the number of game ticks per second
+var TickRate = 20
+
+const inputQueueSize = 256
+
+var errInputQueueFull = game.RejectedAction{Reason: "inputQueueFull"}
+
+type playerInput struct {
+	clientId string
+	event    SocketEventStruct
+}
+
+// clientEvent is an event addressed to a single client of the room
+type clientEvent struct {
+	clientId string
+	event    SocketEventStruct
+}
+
+// witnessedEvent is an event only the players who saw it happen are told about
+type witnessedEvent struct {
+	witnesses []string
+	event     SocketEventStruct
+}
+
+// tickOutbox collects the events produced while a tick is applied,
+// they are sent once the new state is saved
+type tickOutbox struct {
+	roomEvents      []SocketEventStruct
+	clientEvents    []clientEvent
+	witnessedEvents []witnessedEvent
+}
+
+// gameLoop runs the game of one room at a fixed rate. Player inputs are
+// queued and applied in order on the next tick, so the speed of the game
+// doesn't depend on how often a client sends messages.
+type gameLoop struct {
+	roomId  string
+	inputs  chan playerInput
+	stop    chan struct{}
+	done    chan struct{}
+	tick    int64
+	visible map[string][]string
+}
+
+// startGameLoop returns the loop of the room, starting it if it's not running
+// yet. A closed room gets no loop, closing deletes the room before the loop is
+// stopped, so a loop started meanwhile is stopped as well.
+func startGameLoop(hub *Hub, roomId string) (*gameLoop, error) {
+	hub.loopsMutex.Lock()
+	defer hub.loopsMutex.Unlock()
+
+	loop, ok := hub.loops[roomId]
+	if ok {
+		return loop, nil
+	}
+	if _, err := getRoom(hub, roomId); err != nil {
+		return nil, err
+	}
+	loop = &gameLoop{
+		roomId:  roomId,
+		inputs:  make(chan playerInput, inputQueueSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		visible: map[string][]string{},
+	}
+	hub.loops[roomId] = loop
+	go loop.run(hub)
+	return loop, nil
+}
+
+// stopGameLoop stops the loop of the room and waits until its last tick is over,
+// so the game state can be deleted without a tick saving it again
+func stopGameLoop(hub *Hub, roomId string) {
+	hub.loopsMutex.Lock()
+	loop, ok := hub.loops[roomId]
+	if ok {
+		close(loop.stop)
+		delete(hub.loops, roomId)
+	}
+	hub.loopsMutex.Unlock()
+
+	if ok {
+		<-loop.done
+	}
+}
+
+// queueInput hands a gameplay event over to the loop of the client's room
+func queueInput(client *Client, event SocketEventStruct) {
+	loop, err := startGameLoop(client.hub, client.roomId)
+	if err != nil {
+		emitRejectedInput(client, event, err)
+		return
+	}
+	select {
+	case loop.inputs <- playerInput{clientId: client.clientId, event: event}:
+	default:
+		log.Printf("Input queue of room %s is full, dropping %s", loop.roomId, event.EventName)
+		emitRejectedInput(client, event, errInputQueueFull)
+	}
+}
+
+// emitRejectedInput tells the sender why the gameplay input was not applied
+func emitRejectedInput(client *Client, input SocketEventStruct, err error) {
+	event, ok := rejectedActionEvent(input.EventName, err)
+	if !ok {
+		log.Println(err)
+		return
+	}
+	EmitToSpecificClient(client.hub, echoSeq(input, event), client.clientId)
+}
+
+func (loop *gameLoop) run(hub *Hub) {
+	ticker := time.NewTicker(time.Second / time.Duration(TickRate))
+	defer ticker.Stop()
+	defer close(loop.done)
+
+	for {
+		select {
+		case <-loop.stop:
+			return
+		case <-ticker.C:
+			loop.runTick(hub)
+		}
+	}
+}
+
+func (loop *gameLoop) drainInputs() []playerInput {
+	inputs := []playerInput{}
+	for {
+		select {
+		case input := <-loop.inputs:
+			inputs = append(inputs, input)
+		default:
+			return inputs
+		}
+	}
+}
+
+// runTick applies the queued inputs and timers to the game state
+// and sends the room the resulting events and a snapshot
+func (loop *gameLoop) runTick(hub *Hub) {
+	loop.tick++
+	inputs := loop.drainInputs()
+	var outbox *tickOutbox
+	var phaseChanged bool
+
+	hubGame, err := updateDBGameState(hub, loop.roomId, func(gameState *game.GameState) {
+		outbox = &tickOutbox{}
+		phase := gameState.Phase
+		moved := map[string]bool{}
+		for _, input := range inputs {
+			applyInput(gameState, input, moved, outbox)
+		}
+		runBots(gameState, moved, outbox)
+		gameState.Tick(time.Now())
+		phaseChanged = gameState.Phase != phase
+
+		for _, clientId := range gameState.RespawnPlayers(time.Now()) {
+			outbox.roomEvents = append(outbox.roomEvents, SocketEventStruct{
+				EventName: "respawned",
+				EventPayload: structToEventPayload(RespawnedEventPayload{
+					ClientID: clientId,
+					Health:   gameState.Players[clientId].Health,
+				}),
+			})
+		}
+	})
+	if err != nil {
+		return
+	}
+
+	for _, event := range outbox.roomEvents {
+		BroadcastSocketEventToRoom(hub, event, loop.roomId)
+	}
+	for _, event := range outbox.clientEvents {
+		EmitToSpecificClient(hub, event.event, event.clientId)
+	}
+	if len(outbox.witnessedEvents) > 0 {
+		roomClients := getRoomClients(hub, loop.roomId)
+		for _, event := range outbox.witnessedEvents {
+			witnesses := []*Client{}
+			for _, client := range roomClients {
+				if slices.Contains(event.witnesses, client.clientId) {
+					witnesses = append(witnesses, client)
+				}
+			}
+			BroadcastSocketEventToClients(hub, event.event, witnesses)
+		}
+	}
+	if phaseChanged {
+		BroadcastSocketEventToRoom(hub, SocketEventStruct{
+			EventName: "phaseChanged",
+			EventPayload: structToEventPayload(PhasePayload{
+				Phase:  hubGame.Phase,
+				EndsAt: hubGame.PhaseEndsAt,
+			}),
+		}, loop.roomId)
+	}
+	if phaseChanged && hubGame.Phase == game.PhaseEnded {
+		scoreboard := hubGame.Scoreboard()
+		go saveMatch(hub, hubGame, scoreboard)
+		BroadcastSocketEventToRoom(hub, SocketEventStruct{
+			EventName: "matchEnded",
+			EventPayload: structToEventPayload(MatchEndedPayload{
+				Mode:       hubGame.Mode,
+				Winner:     hubGame.Winner,
+				Scores:     hubGame.Scores,
+				Scoreboard: scoreboard,
+			}),
+		}, loop.roomId)
+	}
+	if len(hubGame.Players) == 0 {
+		return
+	}
+
+	loop.sendSnapshots(hub, hubGame)
+}
+
+// sendSnapshots sends every member of the room only the players it can see,
+// together with the players who entered or left its view since the last tick
+func (loop *gameLoop) sendSnapshots(hub *Hub, hubGame *game.GameState) {
+	visible := map[string][]string{}
+	for _, client := range getRoomClients(hub, loop.roomId) {
+		viewer := client.clientId
+		visible[viewer] = hubGame.VisiblePlayers(viewer)
+		recipient := []*Client{client}
+
+		players := []UserGameLocation{}
+		for _, clientId := range visible[viewer] {
+			player := UserGameLocation{
+				User:     getGameUser(hub, hubGame, clientId),
+				Position: hubGame.Locations[clientId],
+				Health:   hubGame.Players[clientId].Health,
+				Team:     hubGame.Players[clientId].Team,
+			}
+			players = append(players, player)
+
+			if clientId != viewer && !slices.Contains(loop.visible[viewer], clientId) {
+				BroadcastSocketEventToClients(hub, SocketEventStruct{
+					EventName:    "enteredView",
+					EventPayload: structToEventPayload(player),
+				}, recipient)
+			}
+		}
+		for _, clientId := range loop.visible[viewer] {
+			if !slices.Contains(visible[viewer], clientId) {
+				BroadcastSocketEventToClients(hub, SocketEventStruct{
+					EventName:    "leftView",
+					EventPayload: map[string]interface{}{"clientId": clientId},
+				}, recipient)
+			}
+		}
+
+		BroadcastSocketEventToClients(hub, SocketEventStruct{
+			EventName: "snapshot",
+			EventPayload: structToEventPayload(SnapshotPayload{
+				Tick:    loop.tick,
+				Players: players,
+				Scores:  hubGame.Scores,
+				Flags:   hubGame.VisibleFlags(viewer),
+			}),
+		}, recipient)
+	}
+	loop.visible = visible
+}
+
+// applyInput applies a single player input to the game state. A player
+// moves at most once per tick, further steps within the same tick are dropped.
+func applyInput(gameState *game.GameState, input playerInput, moved map[string]bool, outbox *tickOutbox) {
+	clientId := input.clientId
+	payload := input.event.EventPayload
+
+	switch input.event.EventName {
+	case "move":
+		_, isInGame := gameState.Locations[clientId]
+		if !isInGame || moved[clientId] {
+			return
+		}
+		moved[clientId] = true
+
+		stepX, _ := payload["x"].(float64)
+		stepY, _ := payload["y"].(float64)
+		if _, err := gameState.MovePlayer(clientId, game.Position{X: int(stepX), Y: int(stepY)}); err != nil {
+			outbox.reject(clientId, input.event, err)
+		}
+
+	case "shoot":
+		directionX, _ := payload["x"].(float64)
+		directionY, _ := payload["y"].(float64)
+		shot, err := gameState.Shoot(clientId, game.Position{X: int(directionX), Y: int(directionY)})
+		if err != nil {
+			outbox.reject(clientId, input.event, err)
+			return
+		}
+
+		// the shot gives away where the shooter stands, it's only sent to those who saw it
+		witnesses := gameState.ShotWitnesses(shot)
+		outbox.witness(witnesses, echoSeq(input.event, SocketEventStruct{
+			EventName:    "shot",
+			EventPayload: structToEventPayload(shot),
+		}))
+		for _, hit := range shot.Hits {
+			victim := gameState.Players[hit.Victim]
+			outbox.witness(witnesses, SocketEventStruct{
+				EventName: "damaged",
+				EventPayload: structToEventPayload(DamagedEventPayload{
+					ClientID: hit.Victim,
+					Shooter:  shot.Shooter,
+					Damage:   hit.Damage,
+					Health:   victim.Health,
+				}),
+			})
+			if !hit.Killed {
+				continue
+			}
+
+			outbox.witness(witnesses, SocketEventStruct{
+				EventName: "killed",
+				EventPayload: structToEventPayload(KilledEventPayload{
+					ClientID:  hit.Victim,
+					Killer:    shot.Shooter,
+					RespawnAt: victim.RespawnAt,
+				}),
+			})
+		}
+
+	case "switchWeapon", "reload":
+		var slot *game.WeaponSlot
+		var err error
+		if input.event.EventName == "switchWeapon" {
+			weapon, _ := payload["weapon"].(float64)
+			slot, err = gameState.SwitchWeapon(clientId, int(weapon))
+		} else {
+			slot, err = gameState.Reload(clientId)
+		}
+		if err != nil {
+			outbox.reject(clientId, input.event, err)
+			return
+		}
+
+		player := gameState.Players[clientId]
+		outbox.roomEvents = append(outbox.roomEvents, SocketEventStruct{
+			EventName: input.event.EventName,
+			EventPayload: structToEventPayload(WeaponEventPayload{
+				ClientID:       clientId,
+				Weapon:         player.ActiveWeapon,
+				Title:          slot.Weapon.Title,
+				Magazine:       slot.Magazine,
+				Reserve:        slot.Reserve,
+				ReloadingUntil: player.ReloadingUntil,
+			}),
+		})
+	}
+}
+
+func (outbox *tickOutbox) witness(witnesses []string, event SocketEventStruct) {
+	outbox.witnessedEvents = append(outbox.witnessedEvents, witnessedEvent{witnesses: witnesses, event: event})
+}
+
+func (outbox *tickOutbox) reject(clientId string, input SocketEventStruct, err error) {
+	event, ok := rejectedActionEvent(input.EventName, err)
+	if !ok {
+		log.Println(err)
+		return
+	}
+	outbox.clientEvents = append(outbox.clientEvents, clientEvent{clientId: clientId, event: echoSeq(input, event)})
+}
+
+// echoSeq copies the sequence number a client may give its inputs into the
+// answer, so the client can tell which input was answered
+func echoSeq(input SocketEventStruct, answer SocketEventStruct) SocketEventStruct {
+	if seq, ok := input.EventPayload["seq"].(float64); ok {
+		answer.EventPayload["seq"] = seq
+	}
+	return answer
+}
+
+// rejectedActionEvent builds the event telling a player why the action was not applied
+func rejectedActionEvent(action string, err error) (SocketEventStruct, bool) {
+	var rejected game.RejectedAction
+	if !errors.As(err, &rejected) {
+		return SocketEventStruct{}, false
+	}
+
+	return SocketEventStruct{
+		EventName: "actionRejected",
+		EventPayload: structToEventPayload(RejectedActionPayload{
+			Action: action,
+			Reason: rejected.Reason,
+		}),
+	}, true
+}
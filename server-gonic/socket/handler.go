@@ -12,7 +12,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/gookit/validate"
 	"github.com/gorilla/websocket"
-	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 const (
@@ -20,69 +20,92 @@ const (
 	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
 	maxMessageSize = 512
+	sendBufferSize = 256
 )
 
 // CreateNewSocketUser creates a new socket user
-func CreateNewSocketUser(hub *Hub, connection *websocket.Conn, userId int, userName string) {
+func CreateNewSocketUser(hub *Hub, connection *websocket.Conn, userId int, userName string, family string, resumeToken string) {
 	uniqueID := uuid.New()
 	client := &Client{
 		hub:                 hub,
 		webSocketConnection: connection,
-		send:                make(chan SocketEventStruct),
+		send:                make(chan SocketEventStruct, sendBufferSize),
+		stopped:             make(chan struct{}),
 		userID:              userId,
 		userName:            userName,
 		clientId:            uniqueID.String(),
+		resumeToken:         resumeToken,
+		family:              family,
 	}
 
+	// events are read only once the hub accepted the connection
 	go client.writePump()
-	go client.readPump()
 
 	client.hub.register <- client
 }
 
-// HandleUserRegisterEvent will handle the Join event for New socket users
+// HandleUserRegisterEvent will handle the Join event for New socket users,
+// a client with a valid resume token gets its previous identity back
 func HandleUserRegisterEvent(hub *Hub, client *Client) {
-	for connected, online := range hub.clients {
-		if connected.userID == client.userID && online {
+	if resumeSession(hub, client) {
+		go client.readPump()
+		return
+	}
+	if previous := connectedClientOfUser(hub, client.userID); previous != nil {
+		switch DuplicateLoginPolicy {
+		case KickOld:
+			if replaceSession(hub, client, previous) {
+				go client.readPump()
+				return
+			}
+		case AllowMultiDevice:
+		default:
+			rejectLogin(client)
 			return
 		}
 	}
 
+	hub.clientsMutex.Lock()
 	hub.clients[client] = true
+	hub.clientsMutex.Unlock()
 
+	go client.readPump()
+	startSession(hub, client)
 	handleSocketPayloadEvents(client, SocketEventStruct{
 		EventName:    "join",
 		EventPayload: map[string]interface{}{"userID": client.userID},
 	})
 }
 
-// HandleUserDisconnectEvent will handle the Disconnect event for socket users
+// HandleUserDisconnectEvent will handle the Disconnect event for socket users.
+// The player is held for the grace period before the others are told it left.
 func HandleUserDisconnectEvent(hub *Hub, client *Client) {
-	_, ok := hub.clients[client]
-	if ok {
-		delete(hub.clients, client)
-		close(client.send)
-
+	removed := hub.removeClient(client)
+	if holdSession(hub, client) {
+		return
+	}
+	// a revoked client was removed already, it still has to leave its game
+	if removed || client.revoked {
 		handleSocketPayloadEvents(client, SocketEventStruct{
 			EventName:    "disconnect",
 			EventPayload: map[string]interface{}{"userID": client.userID},
 		})
 	}
 }
+
+// BroadcastSocketEventToClients will emit the socket event to the given socket users,
+// clients too slow to keep up with their send buffer are dropped
 func BroadcastSocketEventToClients(hub *Hub, payload SocketEventStruct, clients []*Client) {
 	for _, client := range clients {
-		select {
-		case client.send <- payload:
-		default:
-			close(client.send)
-			delete(hub.clients, client)
+		if !hub.sendToClient(client, payload) {
+			hub.removeClient(client)
 		}
 	}
 }
 
 // EmitToSpecificClient will emit the socket event to specific socket user
 func EmitToSpecificClient(hub *Hub, payload SocketEventStruct, clientId string) {
-	for client := range hub.clients {
+	for _, client := range hub.connectedClients() {
 		if client.clientId == clientId {
 			BroadcastSocketEventToClients(hub, payload, []*Client{client})
 			break
@@ -92,12 +115,12 @@ func EmitToSpecificClient(hub *Hub, payload SocketEventStruct, clientId string)
 
 // BroadcastSocketEventToAllClient will emit the socket events to all socket users
 func BroadcastSocketEventToAllClient(hub *Hub, payload SocketEventStruct) {
-	BroadcastSocketEventToClients(hub, payload, maps.Keys(hub.clients))
+	BroadcastSocketEventToClients(hub, payload, hub.connectedClients())
 }
 
 func BroadcastSocketEventToAllExceptOne(hub *Hub, payload SocketEventStruct, clientId string) {
 	clients := []*Client{}
-	for client := range hub.clients {
+	for _, client := range hub.connectedClients() {
 		if client.clientId != clientId {
 			clients = append(clients, client)
 		}
@@ -108,18 +131,22 @@ func BroadcastSocketEventToAllExceptOne(hub *Hub, payload SocketEventStruct, cli
 func getUserByClientID(hub *Hub, clientId string) UserStruct {
 	var user UserStruct
 	user.ClientID = clientId
-	for client := range hub.clients {
+	for _, client := range hub.connectedClients() {
 		if client.clientId == clientId {
 			user.UserID = client.userID
 			user.UserName = client.userName
 		}
 	}
+	if lagging, ok := laggingClient(hub, clientId); ok {
+		user.UserID = lagging.userID
+		user.UserName = lagging.userName
+	}
 	return user
 }
 
 func getAllConnectedUsers(hub *Hub) []UserStruct {
 	var users []UserStruct
-	for singleClient := range hub.clients {
+	for _, singleClient := range hub.connectedClients() {
 		users = append(users, UserStruct{
 			UserID:   singleClient.userID,
 			ClientID: singleClient.clientId,
@@ -129,6 +156,19 @@ func getAllConnectedUsers(hub *Hub) []UserStruct {
 	return users
 }
 
+// structToEventPayload converts a payload struct to the generic map sent over the socket
+func structToEventPayload(payload interface{}) map[string]interface{} {
+	var eventPayload map[string]interface{}
+	marshalled, _ := json.Marshal(payload)
+	json.Unmarshal(marshalled, &eventPayload)
+	return eventPayload
+}
+
+// isGameEvent tells whether the event is only meaningful inside a game room
+func isGameEvent(e SocketEventStruct) bool {
+	return slices.Contains([]string{"move", "shoot", "switchWeapon", "reload"}, e.EventName)
+}
+
 func validateEvent(e SocketEventStruct) bool {
 	validator := validate.Map(e.EventPayload)
 
@@ -141,7 +181,7 @@ func validateEvent(e SocketEventStruct) bool {
 		}
 		return isValid
 	}
-	if e.EventName == "move" {
+	if e.EventName == "move" || e.EventName == "shoot" {
 		validator.StringRule("x", "float|between:-1,1")
 		validator.StringRule("y", "float|between:-1,1")
 		isValid := validator.Validate()
@@ -150,12 +190,22 @@ func validateEvent(e SocketEventStruct) bool {
 		}
 		return isValid
 	}
+	if e.EventName == "switchWeapon" {
+		validator.StringRule("weapon", "float|min:0")
+		isValid := validator.Validate()
+		if !isValid {
+			fmt.Println(validator.Errors)
+		}
+		return isValid
+	}
 	return true
 }
 
 func (c *Client) readPump() {
 
 	defer unRegisterAndCloseConnection(c)
+	// the hub may be waiting for the pump, so it's told before unregistering
+	defer close(c.stopped)
 
 	setSocketPayloadReadConfig(c)
 
@@ -206,14 +256,9 @@ func (c *Client) writePump() {
 				return
 			}
 
+			// every event goes in a message of its own, the client parses them one by one
 			w.Write(finalPayload)
 
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				json.NewEncoder(reqBodyBytes).Encode(<-c.send)
-				w.Write(reqBodyBytes.Bytes())
-			}
-
 			if err := w.Close(); err != nil {
 				return
 			}
@@ -1,33 +1,103 @@
 package socket
 
 import (
-	"shooter/game"
+	"shooter/leaderboard"
+	"shooter/store"
+	"sync"
+	"time"
 
-	"github.com/go-redis/redis/v8"
+	"golang.org/x/exp/maps"
 )
 
 // Hub maintains the set of active clients and broadcasts messages to the clients.
 type Hub struct {
-	clients    map[*Client]bool
-	game       game.GameState
-	db         redis.Client
-	register   chan *Client
-	unregister chan *Client
+	clients      map[*Client]bool
+	clientsMutex sync.RWMutex
+	register     chan *Client
+	unregister   chan *Client
+	games        store.GameStore
+	rooms        store.RoomStore
+	queue        store.QueueStore
+	loops        map[string]*gameLoop
+	loopsMutex   sync.Mutex
+	leaderboards *leaderboard.Leaderboards
+
+	sessions      map[string]*session
+	sessionsMutex sync.Mutex
+	expired       chan *session
+	revoked       chan string
 }
 
 // NewHub will will give an instance of an Hub
-func NewHub(db redis.Client) *Hub {
+func NewHub(games store.GameStore, rooms store.RoomStore, queue store.QueueStore, leaderboards *leaderboard.Leaderboards) *Hub {
 	return &Hub{
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		clients:    make(map[*Client]bool),
-		game:       *game.NewGame(),
-		db:         db,
+		games:      games,
+		rooms:      rooms,
+		queue:      queue,
+		loops:      make(map[string]*gameLoop),
+
+		leaderboards: leaderboards,
+
+		sessions: make(map[string]*session),
+		expired:  make(chan *session),
+		revoked:  make(chan string),
+	}
+}
+
+// RevokeFamily closes the connections opened with tokens of the family
+func (hub *Hub) RevokeFamily(family string) {
+	if family != "" {
+		hub.revoked <- family
+	}
+}
+
+// connectedClients returns the clients online at the moment of the call
+func (hub *Hub) connectedClients() []*Client {
+	hub.clientsMutex.RLock()
+	defer hub.clientsMutex.RUnlock()
+	return maps.Keys(hub.clients)
+}
+
+// sendToClient queues the payload for the client unless its buffer is full
+func (hub *Hub) sendToClient(client *Client, payload SocketEventStruct) bool {
+	hub.clientsMutex.RLock()
+	defer hub.clientsMutex.RUnlock()
+
+	if _, ok := hub.clients[client]; !ok {
+		return true
+	}
+	select {
+	case client.send <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+// removeClient forgets the client and closes its send channel,
+// it reports whether the client was still registered
+func (hub *Hub) removeClient(client *Client) bool {
+	hub.clientsMutex.Lock()
+	defer hub.clientsMutex.Unlock()
+
+	if _, ok := hub.clients[client]; !ok {
+		return false
 	}
+	delete(hub.clients, client)
+	close(client.send)
+	return true
 }
 
 // Run will execute Go Routines to check incoming Socket events
 func (hub *Hub) Run() {
+	clearRooms(hub)
+
+	matchmaking := time.NewTicker(matchmakingInterval)
+	defer matchmaking.Stop()
+
 	for {
 		select {
 		case client := <-hub.register:
@@ -35,6 +105,15 @@ func (hub *Hub) Run() {
 
 		case client := <-hub.unregister:
 			HandleUserDisconnectEvent(hub, client)
+
+		case expired := <-hub.expired:
+			expireSession(hub, expired)
+
+		case family := <-hub.revoked:
+			revokeFamily(hub, family)
+
+		case <-matchmaking.C:
+			matchPlayers(hub)
 		}
 	}
 }
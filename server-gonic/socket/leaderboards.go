@@ -0,0 +1,24 @@
+package socket
+
+import (
+	"errors"
+	"shooter/game"
+	"shooter/leaderboard"
+)
+
+const defaultLeaderboardSize = 10
+const maxLeaderboardSize = 100
+
+var errUnknownLeaderboard = game.RejectedAction{Reason: "unknownLeaderboard"}
+var errNoSeason = game.RejectedAction{Reason: "noSeason"}
+
+// leaderboardError turns the errors caused by a bad request into rejections
+func leaderboardError(err error) error {
+	if errors.Is(err, leaderboard.ErrUnknownStat) || errors.Is(err, leaderboard.ErrUnknownWindow) {
+		return errUnknownLeaderboard
+	}
+	if errors.Is(err, leaderboard.ErrNoSeason) {
+		return errNoSeason
+	}
+	return err
+}
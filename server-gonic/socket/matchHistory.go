@@ -0,0 +1,69 @@
+package socket
+
+import (
+	"log"
+	"shooter/game"
+	"shooter/leaderboard"
+	"shooter/models"
+	"time"
+)
+
+// saveMatch writes the result of a finished match to the database. Every
+// human of the match is recorded, whether still connected or not.
+func saveMatch(hub *Hub, hubGame *game.GameState, scoreboard []game.ScoreboardEntry) {
+	endedAt := time.Now()
+	match := models.Match{
+		Map:          hubGame.Map,
+		Mode:         hubGame.Mode,
+		StartedAt:    hubGame.StartedAt,
+		EndedAt:      endedAt,
+		Duration:     int(endedAt.Sub(hubGame.StartedAt).Seconds()),
+		Participants: []models.MatchParticipant{},
+	}
+	usernames := map[uint]string{}
+	for _, entry := range scoreboard {
+		if entry.UserID == 0 {
+			continue
+		}
+		usernames[uint(entry.UserID)] = entry.UserName
+		match.Participants = append(match.Participants, models.MatchParticipant{
+			UserID:      uint(entry.UserID),
+			Team:        entry.Team,
+			Score:       entry.Score,
+			Kills:       entry.Kills,
+			Deaths:      entry.Deaths,
+			DamageDealt: entry.DamageDealt,
+			Weapon:      entry.Weapon,
+			Placement:   entry.Placement,
+			Won:         !entry.Quit && entry.Placement == 1 && hubGame.Winner != "",
+		})
+	}
+
+	if _, err := match.SaveMatch(); err != nil {
+		log.Println(err)
+		return
+	}
+	recordLeaderboards(hub, match, usernames)
+}
+
+// recordLeaderboards adds the results of the match to the live leaderboards
+func recordLeaderboards(hub *Hub, match models.Match, usernames map[uint]string) {
+	results := []leaderboard.Result{}
+	for _, participant := range match.Participants {
+		skill, err := models.GetUserRating(participant.UserID)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		results = append(results, leaderboard.Result{
+			UserID:   participant.UserID,
+			Username: usernames[participant.UserID],
+			Kills:    participant.Kills,
+			Won:      participant.Won,
+			Rating:   skill.Rating,
+		})
+	}
+	if err := hub.leaderboards.RecordMatch(results); err != nil {
+		log.Println(err)
+	}
+}
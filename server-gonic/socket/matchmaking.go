@@ -0,0 +1,187 @@
+package socket
+
+import (
+	"log"
+	"math"
+	"shooter/models"
+	"shooter/store"
+	"sort"
+	"time"
+)
+
+const matchmakingInterval = time.Second
+
+// MatchSize is the number of players put together into one match
+var MatchSize = 2
+
+// QueueRatingWindow is the largest rating difference between players of a match
+// until their tickets are widened
+var QueueRatingWindow = 200.0
+
+// QueueWidenAfter is the wait after which a ticket accepts players from any region and rating
+var QueueWidenAfter = 30 * time.Second
+
+// QueueTimeout is the wait after which a ticket is dropped from the queue
+var QueueTimeout = 2 * time.Minute
+
+// QueueTicket is a user waiting for a match
+type QueueTicket = store.QueueTicket
+
+func ticketAccepts(ticket QueueTicket, other QueueTicket) bool {
+	if ticket.Mode != other.Mode {
+		return false
+	}
+	if ticket.Widened && other.Widened {
+		return true
+	}
+	return ticket.Region == other.Region && math.Abs(ticket.Rating-other.Rating) <= QueueRatingWindow
+}
+
+func saveTicket(hub *Hub, ticket QueueTicket) error {
+	return hub.queue.Save(ticket)
+}
+
+// getUserRating reads the skill rating of a user, tests swap it for a fake database
+var getUserRating = models.GetUserRating
+
+func enqueue(hub *Hub, client *Client, mode string, region string) (QueueTicket, error) {
+	skill, err := getUserRating(uint(client.userID))
+	if err != nil {
+		log.Println(err)
+	}
+	ticket := QueueTicket{
+		UserID:   client.userID,
+		Mode:     mode,
+		Region:   region,
+		Rating:   skill.Rating,
+		QueuedAt: time.Now(),
+	}
+	return ticket, saveTicket(hub, ticket)
+}
+
+func dequeue(hub *Hub, userId int) error {
+	return hub.queue.Delete(userId)
+}
+
+// loadQueue returns all tickets, the longest waiting first
+func loadQueue(hub *Hub) ([]QueueTicket, error) {
+	tickets, err := hub.queue.Tickets()
+	if err != nil {
+		return []QueueTicket{}, err
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		return tickets[i].QueuedAt.Before(tickets[j].QueuedAt)
+	})
+	return tickets, nil
+}
+
+// matchPlayers drops expired tickets, widens the old ones and groups
+// the online users waiting in the queue into matches
+func matchPlayers(hub *Hub) {
+	tickets, err := loadQueue(hub)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	online := map[int]*Client{}
+	for _, client := range hub.connectedClients() {
+		online[client.userID] = client
+	}
+
+	now := time.Now()
+	waiting := []QueueTicket{}
+	for _, ticket := range tickets {
+		client, isOnline := online[ticket.UserID]
+		waited := now.Sub(ticket.QueuedAt)
+		if waited >= QueueTimeout {
+			dequeue(hub, ticket.UserID)
+			if isOnline {
+				emitQueueEvent(hub, client, "queueTimeout", ticket)
+			}
+			continue
+		}
+		if !ticket.Widened && waited >= QueueWidenAfter {
+			ticket.Widened = true
+			saveTicket(hub, ticket)
+			if isOnline {
+				emitQueueEvent(hub, client, "queueWidened", ticket)
+			}
+		}
+		if isOnline {
+			waiting = append(waiting, ticket)
+		}
+	}
+
+	matched := map[int]bool{}
+	for i, ticket := range waiting {
+		if matched[ticket.UserID] {
+			continue
+		}
+
+		group := []QueueTicket{ticket}
+		for _, candidate := range waiting[i+1:] {
+			if len(group) == MatchSize {
+				break
+			}
+			if matched[candidate.UserID] || !acceptedByAll(group, candidate) {
+				continue
+			}
+			group = append(group, candidate)
+		}
+		if len(group) < MatchSize {
+			continue
+		}
+
+		for _, member := range group {
+			matched[member.UserID] = true
+		}
+		startMatch(hub, group, online)
+	}
+}
+
+func acceptedByAll(group []QueueTicket, candidate QueueTicket) bool {
+	for _, member := range group {
+		if !ticketAccepts(member, candidate) {
+			return false
+		}
+	}
+	return true
+}
+
+// startMatch creates a room for the group and tells every member where to go
+func startMatch(hub *Hub, group []QueueTicket, online map[int]*Client) {
+	room, err := createRoom(hub, Room{Name: group[0].Mode + " " + group[0].Region, Mode: group[0].Mode})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	players := []UserStruct{}
+	for _, member := range group {
+		dequeue(hub, member.UserID)
+		client := online[member.UserID]
+		players = append(players, UserStruct{
+			ClientID: client.clientId,
+			UserID:   client.userID,
+			UserName: client.userName,
+		})
+	}
+
+	for _, member := range group {
+		EmitToSpecificClient(hub, SocketEventStruct{
+			EventName: "matchFound",
+			EventPayload: structToEventPayload(MatchFoundPayload{
+				Room:    room,
+				Players: players,
+			}),
+		}, online[member.UserID].clientId)
+	}
+}
+
+func emitQueueEvent(hub *Hub, client *Client, eventName string, ticket QueueTicket) {
+	EmitToSpecificClient(hub, SocketEventStruct{
+		EventName:    eventName,
+		EventPayload: structToEventPayload(ticket),
+	}, client.clientId)
+}
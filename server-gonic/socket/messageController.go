@@ -1,24 +1,23 @@
 package socket
 
 import (
-	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"shooter/game"
-
-	"github.com/go-redis/redis/v8"
+	"shooter/leaderboard"
 )
 
 func handleSocketPayloadEvents(client *Client, socketEventPayload SocketEventStruct) {
-	ctx := context.Background()
-	redisGameKey := "game"
 	hub := client.hub
 
 	var socketEventResponse SocketEventStruct
 	if !validateEvent(socketEventPayload) {
 		return
 	}
+	if isGameEvent(socketEventPayload) && client.roomId == "" {
+		emitRejectedInput(client, socketEventPayload, game.ErrPlayerNotInGame)
+		return
+	}
 
 	switch socketEventPayload.EventName {
 	case "join":
@@ -40,9 +39,8 @@ func handleSocketPayloadEvents(client *Client, socketEventPayload SocketEventStr
 	case "disconnect":
 		log.Printf("Disconnect Event triggered")
 
-		updateDBGameState(hub, func(gameState game.GameState) {
-			gameState.RemovePlayer(client.clientId)
-		})
+		leaveGame(client)
+		dequeue(hub, client.userID)
 
 		var eventPayload map[string]interface{}
 		disconnectPayload := JoinDisconnectPayload{
@@ -60,9 +58,48 @@ func handleSocketPayloadEvents(client *Client, socketEventPayload SocketEventStr
 	case "joinGame":
 		log.Printf("Game Join Event triggered")
 
-		hubGame, _ := updateDBGameState(hub, func(gameState game.GameState) {
-			gameState.AddPlayer(client.clientId)
+		roomId, _ := socketEventPayload.EventPayload["roomId"].(string)
+		if roomId == "" {
+			roomId = defaultRoomId
+		}
+		if roomId != client.roomId {
+			leaveGame(client)
+		}
+		room, err := joinRoom(hub, client, roomId)
+		if err != nil {
+			emitRejectedAction(client, socketEventPayload.EventName, err)
+			return
+		}
+		if _, err := startGameLoop(hub, roomId); err != nil {
+			leaveRoom(hub, client)
+			emitRejectedAction(client, socketEventPayload.EventName, err)
+			return
+		}
+
+		team, _ := socketEventPayload.EventPayload["team"].(string)
+		loadout := loadUserLoadout(client.userID)
+		var joinErr error
+		hubGame, err := updateDBGameState(hub, roomId, func(gameState *game.GameState) {
+			if len(gameState.Players) == 0 {
+				gameState.SetMap(room.Map)
+				gameState.SetMode(room.Mode)
+				gameState.FriendlyFire = room.FriendlyFire
+				gameState.BotDifficulty = room.BotDifficulty
+			}
+			joinErr = gameState.AddPlayer(client.clientId, loadout, team)
+			if joinErr == nil {
+				gameState.Players[client.clientId].UserID = client.userID
+				gameState.Players[client.clientId].UserName = client.userName
+			}
 		})
+		if err != nil {
+			joinErr = errGameUnavailable
+		}
+		if joinErr != nil {
+			leaveRoom(hub, client)
+			emitRejectedAction(client, socketEventPayload.EventName, joinErr)
+			return
+		}
 
 		var eventPayload map[string]interface{}
 
@@ -74,35 +111,125 @@ func handleSocketPayloadEvents(client *Client, socketEventPayload SocketEventStr
 						UserID:   client.userID,
 						UserName: client.userName,
 					},
-					Position: hubGame.Locations[client.clientId],
+					Health: hubGame.Players[client.clientId].Health,
+					Team:   hubGame.Players[client.clientId].Team,
 				},
 			},
 			Disconnecting: []UserStruct{},
 		}
-		var connected = []UserGameLocation{}
-		for clientId, position := range hubGame.Locations {
-			connected = append(connected, UserGameLocation{
-				User:     getUserByClientID(hub, clientId),
-				Position: position,
-			})
-		}
-		joinGameGuestPayload := JoinDisconnectGameGuestPayload{
-			Connected: connected,
-		}
-
 		marshalledCommon, _ := json.Marshal(joinGameCommonPayload)
 		json.Unmarshal(marshalledCommon, &eventPayload)
-		BroadcastSocketEventToAllExceptOne(client.hub, SocketEventStruct{
+		BroadcastSocketEventToRoomExceptOne(client.hub, SocketEventStruct{
 			EventName:    socketEventPayload.EventName,
 			EventPayload: eventPayload,
 		},
-			client.clientId)
-		marshalledForGuest, _ := json.Marshal(joinGameGuestPayload)
-		eventPayload = map[string]interface{}{}
-		json.Unmarshal(marshalledForGuest, &eventPayload)
+			roomId, client.clientId)
+
+		emitGameState(client, hubGame)
+
+	case "leaveGame":
+		log.Printf("Game Leave Event triggered")
+
+		leaveGame(client)
+
+	case "createRoom":
+		log.Printf("Create Room Event triggered")
+
+		name, _ := socketEventPayload.EventPayload["name"].(string)
+		mode, _ := socketEventPayload.EventPayload["mode"].(string)
+		mapName, _ := socketEventPayload.EventPayload["map"].(string)
+		friendlyFire, _ := socketEventPayload.EventPayload["friendlyFire"].(bool)
+		botDifficulty, _ := socketEventPayload.EventPayload["botDifficulty"].(string)
+		room, err := createRoom(hub, Room{
+			Name:          name,
+			Mode:          mode,
+			Map:           mapName,
+			FriendlyFire:  friendlyFire,
+			BotDifficulty: botDifficulty,
+		})
+		if err != nil {
+			emitRejectedAction(client, socketEventPayload.EventName, err)
+			return
+		}
 		EmitToSpecificClient(client.hub, SocketEventStruct{
-			EventName:    "gameState",
-			EventPayload: eventPayload,
+			EventName:    "roomCreated",
+			EventPayload: structToEventPayload(room),
+		}, client.clientId)
+
+	case "listRooms":
+		log.Printf("List Rooms Event triggered")
+
+		rooms, err := listRooms(hub)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		EmitToSpecificClient(client.hub, SocketEventStruct{
+			EventName:    "rooms",
+			EventPayload: structToEventPayload(RoomsPayload{Rooms: rooms}),
+		}, client.clientId)
+
+	case "queue":
+		log.Printf("Queue Event triggered")
+
+		mode, _ := socketEventPayload.EventPayload["mode"].(string)
+		if mode == "" {
+			mode = game.DefaultModeName
+		}
+		if !game.HasMode(mode) {
+			emitRejectedAction(client, socketEventPayload.EventName, game.ErrUnknownMode)
+			return
+		}
+		region, _ := socketEventPayload.EventPayload["region"].(string)
+		ticket, err := enqueue(hub, client, mode, region)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		emitQueueEvent(hub, client, "queued", ticket)
+
+	case "leaveQueue":
+		log.Printf("Leave Queue Event triggered")
+
+		if err := dequeue(hub, client.userID); err != nil {
+			log.Println(err)
+			return
+		}
+		EmitToSpecificClient(client.hub, SocketEventStruct{
+			EventName:    "queueLeft",
+			EventPayload: map[string]interface{}{"userId": client.userID},
+		}, client.clientId)
+
+	case "leaderboard":
+		log.Printf("Leaderboard Event triggered")
+
+		stat, _ := socketEventPayload.EventPayload["stat"].(string)
+		window, _ := socketEventPayload.EventPayload["window"].(string)
+		if window == "" {
+			window = leaderboard.AllTime
+		}
+		limit, _ := socketEventPayload.EventPayload["limit"].(float64)
+		if limit <= 0 || limit > maxLeaderboardSize {
+			limit = defaultLeaderboardSize
+		}
+		top, err := hub.leaderboards.Top(stat, window, int64(limit))
+		if err != nil {
+			emitRejectedAction(client, socketEventPayload.EventName, leaderboardError(err))
+			return
+		}
+		around, err := hub.leaderboards.AroundUser(stat, window, uint(client.userID))
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		EmitToSpecificClient(client.hub, SocketEventStruct{
+			EventName: "leaderboard",
+			EventPayload: structToEventPayload(LeaderboardPayload{
+				Stat:   stat,
+				Window: window,
+				Top:    top,
+				Around: around,
+			}),
 		}, client.clientId)
 
 	case "message":
@@ -118,59 +245,78 @@ func handleSocketPayloadEvents(client *Client, socketEventPayload SocketEventStr
 		}
 		EmitToSpecificClient(client.hub, socketEventResponse, selectedUserID)
 
-	case "move":
-		log.Printf("Move Event triggered")
-
-		hubGame := game.NewGame()
-		savedGameState, err := client.hub.db.Get(ctx, redisGameKey).Result()
-		if err != redis.Nil {
-			hubGame.UnmarshalBinary([]byte(savedGameState))
-		}
-		stepX := int(socketEventPayload.EventPayload["x"].(float64))
-		stepY := int(socketEventPayload.EventPayload["y"].(float64))
-		_, isInGame := hubGame.Locations[client.clientId]
-		if !isInGame {
-			return
-		}
+	case "move", "shoot", "switchWeapon", "reload":
+		queueInput(client, socketEventPayload)
+	}
+}
 
-		updatedPosition := hubGame.MovePlayer(client.clientId, game.Position{X: stepX, Y: stepY})
+// emitRejectedAction tells the sender only why the action was not applied
+func emitRejectedAction(client *Client, action string, err error) {
+	event, ok := rejectedActionEvent(action, err)
+	if !ok {
+		log.Println(err)
+		return
+	}
+	EmitToSpecificClient(client.hub, event, client.clientId)
+}
 
-		saved, _ := hubGame.MarshalBinary()
-		saveError := client.hub.db.Set(ctx, redisGameKey, saved, 0)
-		if saveError.Err() != nil {
-			fmt.Println(saveError)
+// emitGameState sends the client everything it needs to show the game it plays in
+func emitGameState(client *Client, hubGame *game.GameState) {
+	// positions are only revealed for players in sight, the others come with "enteredView"
+	var connected = []UserGameLocation{}
+	for clientId, player := range hubGame.Players {
+		location := UserGameLocation{
+			User:   getGameUser(client.hub, hubGame, clientId),
+			Health: player.Health,
+			Team:   player.Team,
 		}
-
-		socketEventResponse.EventName = "move"
-		socketEventResponse.EventPayload = map[string]interface{}{
-			"x":        updatedPosition.X,
-			"y":        updatedPosition.Y,
-			"clientId": client.clientId,
-			"userName": client.userName,
+		if hubGame.CanSee(client.clientId, clientId) {
+			location.Position = hubGame.Locations[clientId]
 		}
-		BroadcastSocketEventToAllClient(client.hub, SocketEventStruct{
-			EventName:    socketEventResponse.EventName,
-			EventPayload: socketEventResponse.EventPayload,
-		})
+		connected = append(connected, location)
 	}
+
+	EmitToSpecificClient(client.hub, SocketEventStruct{
+		EventName: "gameState",
+		EventPayload: structToEventPayload(JoinDisconnectGameGuestPayload{
+			Connected:    connected,
+			Map:          hubGame.CurrentMap(),
+			Loadout:      hubGame.Players[client.clientId].Loadout,
+			ActiveWeapon: hubGame.Players[client.clientId].ActiveWeapon,
+			Phase:        hubGame.Phase,
+			PhaseEndsAt:  hubGame.PhaseEndsAt,
+		}),
+	}, client.clientId)
 }
 
-func updateDBGameState(hub *Hub, cb func(gameState game.GameState)) (game.GameState, error) {
-	ctx := context.Background()
-	redisGameKey := "game"
-	hubGame := game.NewGame()
-	savedGameState, err := hub.db.Get(ctx, redisGameKey).Result()
-	if err != redis.Nil {
-		hubGame.UnmarshalBinary([]byte(savedGameState))
+// leaveGame takes the client out of its room and tells the remaining members
+func leaveGame(client *Client) {
+	roomId := leaveRoom(client.hub, client)
+	if roomId == "" {
+		return
 	}
 
-	cb(*hubGame)
+	BroadcastSocketEventToRoom(client.hub, SocketEventStruct{
+		EventName: "joinGame",
+		EventPayload: structToEventPayload(JoinDisconnectGameCommonPayload{
+			Joining: []UserGameLocation{},
+			Disconnecting: []UserStruct{
+				{
+					ClientID: client.clientId,
+					UserID:   client.userID,
+					UserName: client.userName,
+				},
+			},
+		}),
+	}, roomId)
+}
 
-	saved, _ := hubGame.MarshalBinary()
-	saveError := hub.db.Set(ctx, redisGameKey, saved, 0)
-	if saveError.Err() != nil {
-		fmt.Println(saveError)
-		return *hubGame, err
+// updateDBGameState applies the change to the game state of the room atomically,
+// the change is replayed if another client updated the state concurrently
+func updateDBGameState(hub *Hub, roomId string, cb func(gameState *game.GameState)) (*game.GameState, error) {
+	hubGame, err := hub.games.Update(roomId, cb)
+	if err != nil {
+		log.Println(err)
 	}
-	return *hubGame, nil
+	return hubGame, err
 }
@@ -0,0 +1,302 @@
+package socket
+
+import (
+	"shooter/game"
+	"shooter/models"
+	"shooter/rating"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+func sendEvent(client *Client, eventName string, payload map[string]interface{}) {
+	handleSocketPayloadEvents(client, SocketEventStruct{EventName: eventName, EventPayload: payload})
+}
+
+func assertRejected(t *testing.T, client *Client, action string, reason string) {
+	t.Helper()
+	rejected := sentEvent(t, client, "actionRejected")
+	if rejected["action"] != action || rejected["reason"] != reason {
+		t.Errorf("rejected = %v, want %s rejected for %s", rejected, action, reason)
+	}
+}
+
+func roomMembers(t *testing.T, hub *Hub, roomId string) []string {
+	t.Helper()
+	members, err := hub.rooms.Members(roomId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return members
+}
+
+func TestJoinGame(t *testing.T) {
+	fakeArsenal(t, nil)
+
+	t.Run("the default room", func(t *testing.T) {
+		hub := newTestHub()
+		first := newTestClient(hub, 1)
+		second := newTestClient(hub, 2)
+		sendEvent(first, "joinGame", map[string]interface{}{})
+		t.Cleanup(func() { leaveGame(first) })
+		sendEvent(second, "joinGame", map[string]interface{}{})
+		t.Cleanup(func() { leaveGame(second) })
+
+		if first.roomId != defaultRoomId || second.roomId != defaultRoomId {
+			t.Errorf("rooms = %q, %q, want both in %q", first.roomId, second.roomId, defaultRoomId)
+		}
+		members := roomMembers(t, hub, defaultRoomId)
+		if !slices.Contains(members, first.clientId) || !slices.Contains(members, second.clientId) {
+			t.Errorf("members = %v, want both clients", members)
+		}
+		hubGame, err := hub.games.Load(defaultRoomId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, client := range []*Client{first, second} {
+			player, ok := hubGame.Players[client.clientId]
+			if !ok {
+				t.Fatalf("%s isn't in the game", client.userName)
+			}
+			if player.UserID != client.userID || player.UserName != client.userName {
+				t.Errorf("player = %d %q, want %d %q", player.UserID, player.UserName, client.userID, client.userName)
+			}
+		}
+
+		sentEvent(t, second, "gameState")
+		joining, _ := sentEvent(t, first, "joinGame")["joining"].([]interface{})
+		if len(joining) != 1 {
+			t.Fatalf("joining = %v, want the second client", joining)
+		}
+		user, _ := joining[0].(map[string]interface{})["user"].(map[string]interface{})
+		if user["clientId"] != second.clientId {
+			t.Errorf("joining = %v, want %s", user["clientId"], second.clientId)
+		}
+	})
+
+	t.Run("an unknown room", func(t *testing.T) {
+		hub := newTestHub()
+		client := newTestClient(hub, 1)
+		sendEvent(client, "joinGame", map[string]interface{}{"roomId": "unknown"})
+
+		assertRejected(t, client, "joinGame", errRoomNotFound.Reason)
+		if client.roomId != "" {
+			t.Errorf("roomId = %q, want none", client.roomId)
+		}
+	})
+
+	t.Run("a created room", func(t *testing.T) {
+		hub := newTestHub()
+		client := newTestClient(hub, 1)
+		sendEvent(client, "createRoom", map[string]interface{}{"name": "room", "mode": "teamDeathmatch"})
+		roomId, _ := sentEvent(t, client, "roomCreated")["id"].(string)
+
+		sendEvent(client, "joinGame", map[string]interface{}{"roomId": roomId, "team": "blue"})
+		t.Cleanup(func() { leaveGame(client) })
+		hubGame, err := hub.games.Load(roomId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hubGame.Mode != "teamDeathmatch" {
+			t.Errorf("mode = %q, want the room's", hubGame.Mode)
+		}
+		if team := hubGame.Players[client.clientId].Team; team != "blue" {
+			t.Errorf("team = %q, want blue", team)
+		}
+	})
+}
+
+func TestLeaveGame(t *testing.T) {
+	fakeArsenal(t, nil)
+
+	t.Run("the others are told", func(t *testing.T) {
+		hub := newTestHub()
+		leaving := newTestClient(hub, 1)
+		staying := newTestClient(hub, 2)
+		sendEvent(leaving, "joinGame", map[string]interface{}{})
+		sendEvent(staying, "joinGame", map[string]interface{}{})
+		t.Cleanup(func() { leaveGame(staying) })
+		sentEvent(t, staying, "gameState")
+
+		sendEvent(leaving, "leaveGame", map[string]interface{}{})
+
+		if leaving.roomId != "" {
+			t.Errorf("roomId = %q, want none", leaving.roomId)
+		}
+		if members := roomMembers(t, hub, defaultRoomId); slices.Contains(members, leaving.clientId) {
+			t.Errorf("members = %v, want the leaving client gone", members)
+		}
+		hubGame, err := hub.games.Load(defaultRoomId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := hubGame.Players[leaving.clientId]; ok {
+			t.Error("the player is still in the game")
+		}
+		disconnecting, _ := sentEvent(t, staying, "joinGame")["disconnecting"].([]interface{})
+		if len(disconnecting) != 1 || disconnecting[0].(map[string]interface{})["clientId"] != leaving.clientId {
+			t.Errorf("disconnecting = %v, want %s", disconnecting, leaving.clientId)
+		}
+	})
+
+	t.Run("the last member closes the room", func(t *testing.T) {
+		hub := newTestHub()
+		client := newTestClient(hub, 1)
+		sendEvent(client, "createRoom", map[string]interface{}{"name": "room"})
+		roomId, _ := sentEvent(t, client, "roomCreated")["id"].(string)
+		sendEvent(client, "joinGame", map[string]interface{}{"roomId": roomId})
+
+		sendEvent(client, "leaveGame", map[string]interface{}{})
+
+		if _, err := getRoom(hub, roomId); err != errRoomNotFound {
+			t.Errorf("room error = %v, want %v", err, errRoomNotFound)
+		}
+		games, err := hub.games.List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := games[roomId]; ok {
+			t.Error("the game of the room is still stored")
+		}
+		hub.loopsMutex.Lock()
+		_, running := hub.loops[roomId]
+		hub.loopsMutex.Unlock()
+		if running {
+			t.Error("the loop of the room is still running")
+		}
+	})
+}
+
+func TestMove(t *testing.T) {
+	fakeArsenal(t, nil)
+
+	t.Run("outside a game", func(t *testing.T) {
+		client := newTestClient(newTestHub(), 1)
+		sendEvent(client, "move", map[string]interface{}{"x": 1.0, "y": 0.0})
+		assertRejected(t, client, "move", game.ErrPlayerNotInGame.Reason)
+	})
+
+	t.Run("the next tick moves the player", func(t *testing.T) {
+		hub := newTestHub()
+		client := newTestClient(hub, 1)
+		sendEvent(client, "joinGame", map[string]interface{}{})
+		t.Cleanup(func() { leaveGame(client) })
+		hubGame, err := hub.games.Load(defaultRoomId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		from := *hubGame.Locations[client.clientId]
+		// players spawn in the corners, the step leads into the field
+		step := game.Position{X: 1, Y: 1}
+		if from.X > 0 {
+			step.X = -1
+		}
+		if from.Y > 0 {
+			step.Y = -1
+		}
+
+		sendEvent(client, "move", map[string]interface{}{"x": float64(step.X), "y": float64(step.Y)})
+
+		want := game.Position{X: from.X + step.X, Y: from.Y + step.Y}
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			hubGame, err := hub.games.Load(defaultRoomId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if at := *hubGame.Locations[client.clientId]; at == want {
+				break
+			} else if time.Now().After(deadline) {
+				t.Fatalf("player is at %v, want %v", at, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
+
+func TestQueue(t *testing.T) {
+	getUserRating = func(userId uint) (rating.Rating, error) {
+		return rating.Default(), nil
+	}
+	t.Cleanup(func() { getUserRating = models.GetUserRating })
+
+	t.Run("joining and leaving", func(t *testing.T) {
+		hub := newTestHub()
+		client := newTestClient(hub, 1)
+		sendEvent(client, "queue", map[string]interface{}{"region": "eu"})
+
+		queued := sentEvent(t, client, "queued")
+		if queued["mode"] != game.DefaultModeName || queued["region"] != "eu" {
+			t.Errorf("ticket = %v, want the default mode in eu", queued)
+		}
+		tickets, err := hub.queue.Tickets()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tickets) != 1 || tickets[0].UserID != client.userID {
+			t.Fatalf("tickets = %v, want the client's", tickets)
+		}
+
+		sendEvent(client, "leaveQueue", map[string]interface{}{})
+		sentEvent(t, client, "queueLeft")
+		if tickets, _ := hub.queue.Tickets(); len(tickets) != 0 {
+			t.Errorf("tickets = %v, want none", tickets)
+		}
+	})
+
+	t.Run("an unknown mode", func(t *testing.T) {
+		hub := newTestHub()
+		client := newTestClient(hub, 1)
+		sendEvent(client, "queue", map[string]interface{}{"mode": "unknown"})
+
+		assertRejected(t, client, "queue", game.ErrUnknownMode.Reason)
+		if tickets, _ := hub.queue.Tickets(); len(tickets) != 0 {
+			t.Errorf("tickets = %v, want none", tickets)
+		}
+	})
+}
+
+func TestRejectedInputEchoesSeq(t *testing.T) {
+	tests := []struct {
+		name   string
+		inRoom bool
+		reason string
+	}{
+		{name: "outside a game", inRoom: false, reason: game.ErrPlayerNotInGame.Reason},
+		{name: "the input queue is full", inRoom: true, reason: errInputQueueFull.Reason},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := newTestHub()
+			client := newTestClient(hub, 1)
+			if test.inRoom {
+				// a loop which never takes inputs
+				client.roomId = "full"
+				hub.loops[client.roomId] = &gameLoop{roomId: client.roomId, inputs: make(chan playerInput)}
+			}
+
+			sendEvent(client, "shoot", map[string]interface{}{"x": 1.0, "y": 0.0, "seq": 7.0})
+
+			rejected := sentEvent(t, client, "actionRejected")
+			if rejected["reason"] != test.reason || rejected["seq"] != 7.0 {
+				t.Errorf("rejected = %v, want %s for input 7", rejected, test.reason)
+			}
+		})
+	}
+}
+
+func TestShotEchoesSeq(t *testing.T) {
+	fakeArsenal(t, nil)
+	hub := newTestHub()
+	client := newTestClient(hub, 1)
+	sendEvent(client, "joinGame", map[string]interface{}{})
+	t.Cleanup(func() { leaveGame(client) })
+
+	sendEvent(client, "shoot", map[string]interface{}{"x": 1.0, "y": 0.0, "seq": 3.0})
+
+	if shot := sentEvent(t, client, "shot"); shot["seq"] != 3.0 {
+		t.Errorf("shot = %v, want the answer to input 3", shot)
+	}
+}
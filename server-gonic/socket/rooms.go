@@ -0,0 +1,192 @@
+package socket
+
+import (
+	"errors"
+	"log"
+	"shooter/game"
+	"shooter/store"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+const defaultRoomId = "default"
+
+// EmptyRoomTimeout is how long a new room waits for its first member before it's closed
+var EmptyRoomTimeout = time.Minute
+
+var errRoomNotFound = game.RejectedAction{Reason: "roomNotFound"}
+var errGameUnavailable = game.RejectedAction{Reason: "gameUnavailable"}
+
+// Room is a single match running on the server
+type Room = store.Room
+
+// createRoom saves the room settings, a room without an id gets a new one
+func createRoom(hub *Hub, room Room) (Room, error) {
+	if room.ID == "" {
+		room.ID = uuid.New().String()
+	}
+	if room.Mode == "" {
+		room.Mode = game.DefaultModeName
+	}
+	if !game.HasMode(room.Mode) {
+		return room, game.ErrUnknownMode
+	}
+	if room.Map == "" {
+		room.Map = game.DefaultMapName
+	}
+	if !game.HasMap(room.Map) {
+		return room, game.ErrUnknownMap
+	}
+	if room.BotDifficulty == "" {
+		room.BotDifficulty = DefaultBotDifficulty
+	}
+	if !game.HasBotDifficulty(room.BotDifficulty) {
+		return room, game.ErrUnknownBotDifficulty
+	}
+
+	if err := hub.rooms.Save(room); err != nil {
+		return room, err
+	}
+	if room.ID != defaultRoomId {
+		time.AfterFunc(EmptyRoomTimeout, func() {
+			closeEmptyRoom(hub, room.ID)
+		})
+	}
+	return room, nil
+}
+
+// closeEmptyRoom closes the room unless somebody is in it. Nobody can join
+// once the room is deleted, so its loop and game go without a race.
+func closeEmptyRoom(hub *Hub, roomId string) {
+	closed, err := hub.rooms.DeleteIfEmpty(roomId)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if !closed {
+		return
+	}
+	stopGameLoop(hub, roomId)
+	hub.games.Delete(roomId)
+}
+
+func getRoom(hub *Hub, roomId string) (Room, error) {
+	room, err := hub.rooms.Get(roomId)
+	if errors.Is(err, store.ErrRoomNotFound) {
+		return room, errRoomNotFound
+	}
+	return room, err
+}
+
+func listRooms(hub *Hub) ([]Room, error) {
+	return hub.rooms.List()
+}
+
+// joinRoom adds the client to the members of the room
+func joinRoom(hub *Hub, client *Client, roomId string) (Room, error) {
+	if roomId == defaultRoomId {
+		if _, err := createRoom(hub, Room{ID: defaultRoomId, Name: defaultRoomId}); err != nil {
+			return Room{}, err
+		}
+	}
+	room, err := getRoom(hub, roomId)
+	if err != nil {
+		return room, err
+	}
+	// the room may have been closed since it was read
+	err = hub.rooms.AddMember(roomId, client.clientId)
+	if errors.Is(err, store.ErrRoomNotFound) {
+		return room, errRoomNotFound
+	}
+	if err != nil {
+		return room, err
+	}
+	client.roomId = roomId
+	return room, nil
+}
+
+// leaveRoom removes the client from its room. Rooms left without members are
+// closed, the default room is kept but idles until somebody joins it again
+func leaveRoom(hub *Hub, client *Client) string {
+	roomId := client.roomId
+	if roomId == "" {
+		return ""
+	}
+	client.roomId = ""
+
+	updateDBGameState(hub, roomId, func(gameState *game.GameState) {
+		gameState.RemovePlayer(client.clientId)
+	})
+	members, err := hub.rooms.RemoveMember(roomId, client.clientId)
+	if err != nil {
+		log.Println(err)
+		return roomId
+	}
+
+	if members > 0 {
+		return roomId
+	}
+	if roomId != defaultRoomId {
+		closeEmptyRoom(hub, roomId)
+	} else {
+		stopGameLoop(hub, roomId)
+		hub.games.Delete(roomId)
+	}
+	return roomId
+}
+
+func getRoomClients(hub *Hub, roomId string) []*Client {
+	members, err := hub.rooms.Members(roomId)
+	if err != nil {
+		return []*Client{}
+	}
+
+	clients := []*Client{}
+	for _, client := range hub.connectedClients() {
+		if slices.Contains(members, client.clientId) {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+// BroadcastSocketEventToRoom will emit the socket events to all members of the room
+func BroadcastSocketEventToRoom(hub *Hub, payload SocketEventStruct, roomId string) {
+	BroadcastSocketEventToClients(hub, payload, getRoomClients(hub, roomId))
+}
+
+func BroadcastSocketEventToRoomExceptOne(hub *Hub, payload SocketEventStruct, roomId string, clientId string) {
+	clients := []*Client{}
+	for _, client := range getRoomClients(hub, roomId) {
+		if client.clientId != clientId {
+			clients = append(clients, client)
+		}
+	}
+	BroadcastSocketEventToClients(hub, payload, clients)
+}
+
+// clearRooms drops the rooms left by a previous run of the server,
+// their members went away together with the old connections
+func clearRooms(hub *Hub) {
+	rooms, err := hub.rooms.List()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, room := range rooms {
+		hub.rooms.Delete(room.ID)
+	}
+
+	// games are listed on their own, a crash may have left some without a room
+	games, err := hub.games.List()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for gameId := range games {
+		hub.games.Delete(gameId)
+	}
+}
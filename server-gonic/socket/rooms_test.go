@@ -0,0 +1,70 @@
+package socket
+
+import "testing"
+
+func loopRunning(hub *Hub, roomId string) bool {
+	hub.loopsMutex.Lock()
+	defer hub.loopsMutex.Unlock()
+	_, ok := hub.loops[roomId]
+	return ok
+}
+
+func TestCloseEmptyRoom(t *testing.T) {
+	fakeArsenal(t, nil)
+
+	tests := []struct {
+		name   string
+		joined bool
+		closed bool
+	}{
+		{name: "a room nobody joined is closed", joined: false, closed: true},
+		{name: "a room with a player is kept", joined: true, closed: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := newTestHub()
+			room, err := createRoom(hub, Room{Name: "room"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.joined {
+				client := newTestClient(hub, 1)
+				sendEvent(client, "joinGame", map[string]interface{}{"roomId": room.ID})
+				t.Cleanup(func() { leaveGame(client) })
+			}
+
+			closeEmptyRoom(hub, room.ID)
+
+			_, err = getRoom(hub, room.ID)
+			if closed := err == errRoomNotFound; closed != test.closed {
+				t.Errorf("closed = %v, want %v", closed, test.closed)
+			}
+			if running := loopRunning(hub, room.ID); running == test.closed {
+				t.Errorf("loop running = %v, want %v", running, !test.closed)
+			}
+		})
+	}
+}
+
+func TestLateInputDoesntReopenRoom(t *testing.T) {
+	hub := newTestHub()
+	room, err := createRoom(hub, Room{Name: "room"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeEmptyRoom(hub, room.ID)
+
+	// the client still believes to be in the room
+	client := newTestClient(hub, 1)
+	client.roomId = room.ID
+	sendEvent(client, "move", map[string]interface{}{"x": 1.0, "y": 0.0})
+
+	assertRejected(t, client, "move", errRoomNotFound.Reason)
+	if loopRunning(hub, room.ID) {
+		t.Error("a loop was started for the closed room")
+	}
+	if games, _ := hub.games.List(); games[room.ID] != nil {
+		t.Error("the game of the closed room was created again")
+	}
+}
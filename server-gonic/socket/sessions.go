@@ -0,0 +1,207 @@
+package socket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+)
+
+// ResumeGracePeriod is how long the player of a dropped connection is held for a reconnect
+var ResumeGracePeriod = 30 * time.Second
+
+// session ties a resume token to the identity of a connection. While the
+// connection is down the session is lagging, client is the dropped one
+// and expiry runs until the identity is given up.
+type session struct {
+	token   string
+	client  *Client
+	lagging bool
+	expiry  *time.Timer
+}
+
+type SessionPayload struct {
+	ClientID    string `json:"clientId"`
+	ResumeToken string `json:"resumeToken"`
+	GracePeriod int    `json:"gracePeriod"` // seconds
+	Resumed     bool   `json:"resumed"`
+}
+
+func newResumeToken() string {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		log.Println(err)
+	}
+	return hex.EncodeToString(token)
+}
+
+// startSession hands the client a resume token for its identity
+func startSession(hub *Hub, client *Client) {
+	hub.sessionsMutex.Lock()
+	client.resumeToken = newResumeToken()
+	hub.sessions[client.resumeToken] = &session{token: client.resumeToken, client: client}
+	hub.sessionsMutex.Unlock()
+
+	emitSession(hub, client, false)
+}
+
+func emitSession(hub *Hub, client *Client, resumed bool) {
+	EmitToSpecificClient(hub, SocketEventStruct{
+		EventName: "session",
+		EventPayload: structToEventPayload(SessionPayload{
+			ClientID:    client.clientId,
+			ResumeToken: client.resumeToken,
+			GracePeriod: int(ResumeGracePeriod.Seconds()),
+			Resumed:     resumed,
+		}),
+	}, client.clientId)
+}
+
+// resumeSession gives the connecting client the identity of the session its
+// resume token belongs to. An older connection still holding the identity
+// is dropped. It reports whether the session was resumed.
+func resumeSession(hub *Hub, client *Client) bool {
+	if client.resumeToken == "" {
+		return false
+	}
+	hub.sessionsMutex.Lock()
+	held, ok := hub.sessions[client.resumeToken]
+	if !ok || held.client.userID != client.userID {
+		hub.sessionsMutex.Unlock()
+		client.resumeToken = ""
+		return false
+	}
+	previous := held.client
+	if held.expiry != nil {
+		held.expiry.Stop()
+	}
+	held.client = client
+	held.lagging = false
+	held.expiry = nil
+	client.clientId = previous.clientId
+	hub.sessionsMutex.Unlock()
+
+	// the room is handed over once the older connection's pump stopped, until
+	// then it may still join or leave a game. Removing the client closes its
+	// connection, which ends the pump.
+	hub.removeClient(previous)
+	<-previous.stopped
+	client.roomId = previous.roomId
+
+	hub.clientsMutex.Lock()
+	hub.clients[client] = true
+	hub.clientsMutex.Unlock()
+
+	emitSession(hub, client, true)
+	if client.roomId == "" {
+		return true
+	}
+	hubGame, err := hub.games.Load(client.roomId)
+	if err != nil {
+		log.Println(err)
+		return true
+	}
+	if _, ok := hubGame.Players[client.clientId]; ok {
+		emitGameState(client, hubGame)
+	}
+	BroadcastSocketEventToRoomExceptOne(hub, SocketEventStruct{
+		EventName:    "playerResumed",
+		EventPayload: map[string]interface{}{"clientId": client.clientId},
+	}, client.roomId, client.clientId)
+	return true
+}
+
+// holdSession keeps the identity and the player of a dropped client for the
+// grace period, the room is told the player is lagging. It reports whether
+// the client had a session to hold.
+func holdSession(hub *Hub, client *Client) bool {
+	hub.sessionsMutex.Lock()
+	held, ok := hub.sessions[client.resumeToken]
+	if !ok || held.client != client || held.lagging {
+		hub.sessionsMutex.Unlock()
+		return false
+	}
+	held.lagging = true
+	held.expiry = time.AfterFunc(ResumeGracePeriod, func() {
+		hub.expired <- held
+	})
+	hub.sessionsMutex.Unlock()
+
+	if client.roomId != "" {
+		BroadcastSocketEventToRoom(hub, SocketEventStruct{
+			EventName:    "playerLagging",
+			EventPayload: map[string]interface{}{"clientId": client.clientId},
+		}, client.roomId)
+	}
+	return true
+}
+
+// expireSession gives up the identity of a client which didn't come back in time
+func expireSession(hub *Hub, expired *session) {
+	hub.sessionsMutex.Lock()
+	if !expired.lagging || hub.sessions[expired.token] != expired {
+		hub.sessionsMutex.Unlock()
+		return
+	}
+	delete(hub.sessions, expired.token)
+	hub.sessionsMutex.Unlock()
+
+	handleSocketPayloadEvents(expired.client, SocketEventStruct{
+		EventName:    "disconnect",
+		EventPayload: map[string]interface{}{"userID": expired.client.userID},
+	})
+}
+
+// laggingClient returns the dropped client holding the identity, if any
+func laggingClient(hub *Hub, clientId string) (*Client, bool) {
+	hub.sessionsMutex.Lock()
+	defer hub.sessionsMutex.Unlock()
+	for _, held := range hub.sessions {
+		if held.lagging && held.client.clientId == clientId {
+			return held.client, true
+		}
+	}
+	return nil, false
+}
+
+// revokeFamily drops the connections and the sessions of a revoked token
+// family. Their players leave right away instead of being held for a
+// reconnect, connected clients are told why before they're closed. A lagging
+// client has no pump left, so it's taken out of its game here, a connected
+// one leaves once its pump stopped and unregistered it.
+func revokeFamily(hub *Hub, family string) {
+	lagging := []*Client{}
+	hub.sessionsMutex.Lock()
+	for token, held := range hub.sessions {
+		if held.client.family != family {
+			continue
+		}
+		if held.expiry != nil {
+			held.expiry.Stop()
+		}
+		delete(hub.sessions, token)
+		if held.lagging {
+			lagging = append(lagging, held.client)
+		}
+	}
+	hub.sessionsMutex.Unlock()
+
+	for _, client := range hub.connectedClients() {
+		if client.family != family {
+			continue
+		}
+		client.revoked = true
+		hub.sendToClient(client, SocketEventStruct{
+			EventName:    "sessionRevoked",
+			EventPayload: map[string]interface{}{"clientId": client.clientId},
+		})
+		hub.removeClient(client)
+	}
+
+	for _, client := range lagging {
+		handleSocketPayloadEvents(client, SocketEventStruct{
+			EventName:    "disconnect",
+			EventPayload: map[string]interface{}{"userID": client.userID},
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package socket
+
+import "testing"
+
+func TestRevokeFamily(t *testing.T) {
+	defaultPolicy := DuplicateLoginPolicy
+	t.Cleanup(func() { DuplicateLoginPolicy = defaultPolicy })
+	DuplicateLoginPolicy = AllowMultiDevice
+
+	hub, url := newTestServer(t)
+	revoked := dial(t, url+"?family=revoked")
+	revokedSession := readEvent(t, revoked, "session")
+	kept := dial(t, url+"?family=kept")
+	readEvent(t, kept, "session")
+
+	hub.RevokeFamily("revoked")
+
+	readEvent(t, revoked, "sessionRevoked")
+	assertClosed(t, revoked)
+	// the others are told once the revoked connection's pump unregistered it
+	if left := readEvent(t, kept, "disconnect"); left["clientId"] != revokedSession["clientId"] {
+		t.Errorf("disconnected = %v, want %v", left["clientId"], revokedSession["clientId"])
+	}
+	if count := connectionsOfUser(hub, 1); count != 1 {
+		t.Errorf("connections = %d, want 1", count)
+	}
+	hub.sessionsMutex.Lock()
+	sessions := len(hub.sessions)
+	hub.sessionsMutex.Unlock()
+	if sessions != 1 {
+		t.Errorf("sessions = %d, want only the one of the kept family", sessions)
+	}
+}
+
+func TestResumeHandsRoomOverAfterPump(t *testing.T) {
+	tests := []struct {
+		name string
+		// lastEvent is read by the older connection's pump before it stops
+		lastEvent *SocketEventStruct
+		roomId    string
+	}{
+		{name: "the room of the older connection", roomId: defaultRoomId},
+		{
+			name:      "the room as the older connection's pump left it",
+			lastEvent: &SocketEventStruct{EventName: "leaveGame", EventPayload: map[string]interface{}{}},
+			roomId:    "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fakeArsenal(t, nil)
+			hub := newTestHub()
+			previous := newTestClient(hub, 1)
+			startSession(hub, previous)
+			handleSocketPayloadEvents(previous, SocketEventStruct{
+				EventName:    "joinGame",
+				EventPayload: map[string]interface{}{},
+			})
+
+			// stands in for the pump, which stops once the connection was closed
+			go func() {
+				for range previous.send {
+				}
+				if test.lastEvent != nil {
+					handleSocketPayloadEvents(previous, *test.lastEvent)
+				}
+				close(previous.stopped)
+			}()
+
+			client := newTestClient(hub, 1)
+			hub.clientsMutex.Lock()
+			delete(hub.clients, client)
+			hub.clientsMutex.Unlock()
+			client.resumeToken = previous.resumeToken
+
+			if !resumeSession(hub, client) {
+				t.Fatal("session wasn't resumed")
+			}
+			t.Cleanup(func() { leaveGame(client) })
+			if client.clientId != previous.clientId {
+				t.Errorf("clientId = %s, want %s", client.clientId, previous.clientId)
+			}
+			if client.roomId != test.roomId {
+				t.Errorf("roomId = %q, want %q", client.roomId, test.roomId)
+			}
+		})
+	}
+}
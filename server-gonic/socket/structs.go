@@ -2,6 +2,8 @@ package socket
 
 import (
 	"shooter/game"
+	"shooter/leaderboard"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -15,7 +17,9 @@ type UserStruct struct {
 
 type UserGameLocation struct {
 	User     UserStruct     `json:"user"`
-	Position *game.Position `json:"position"`
+	Position *game.Position `json:"position,omitempty"`
+	Health   int            `json:"health"`
+	Team     string         `json:"team,omitempty"`
 }
 
 // SocketEventStruct struct of socket events
@@ -39,9 +43,17 @@ type Client struct {
 	hub                 *Hub
 	webSocketConnection *websocket.Conn
 	send                chan SocketEventStruct
-	clientId            string
-	userID              int
-	userName            string
+	// stopped is closed once readPump handled the last event of the client
+	stopped     chan struct{}
+	clientId    string
+	userID      int
+	userName    string
+	roomId      string
+	resumeToken string
+	// family of the tokens the connection was opened with
+	family string
+	// revoked is set on the hub goroutine once the family was revoked
+	revoked bool
 }
 
 // JoinDisconnectPayload will have struct for payload of join disconnect
@@ -56,5 +68,77 @@ type JoinDisconnectGameCommonPayload struct {
 }
 
 type JoinDisconnectGameGuestPayload struct {
-	Connected []UserGameLocation `json:"connected"`
+	Connected    []UserGameLocation `json:"connected"`
+	Map          *game.Map          `json:"map"`
+	Loadout      []*game.WeaponSlot `json:"loadout"`
+	ActiveWeapon int                `json:"activeWeapon"`
+	Phase        string             `json:"phase"`
+	PhaseEndsAt  time.Time          `json:"phaseEndsAt"`
+}
+
+type DamagedEventPayload struct {
+	ClientID string `json:"clientId"`
+	Shooter  string `json:"shooter"`
+	Damage   int    `json:"damage"`
+	Health   int    `json:"health"`
+}
+
+type KilledEventPayload struct {
+	ClientID  string    `json:"clientId"`
+	Killer    string    `json:"killer"`
+	RespawnAt time.Time `json:"respawnAt"`
+}
+
+type RespawnedEventPayload struct {
+	ClientID string `json:"clientId"`
+	Health   int    `json:"health"`
+}
+
+type WeaponEventPayload struct {
+	ClientID       string    `json:"clientId"`
+	Weapon         int       `json:"weapon"`
+	Title          string    `json:"title"`
+	Magazine       int       `json:"magazine"`
+	Reserve        int       `json:"reserve"`
+	ReloadingUntil time.Time `json:"reloadingUntil"`
+}
+
+type RejectedActionPayload struct {
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+type RoomsPayload struct {
+	Rooms []Room `json:"rooms"`
+}
+
+type MatchFoundPayload struct {
+	Room    Room         `json:"room"`
+	Players []UserStruct `json:"players"`
+}
+
+type SnapshotPayload struct {
+	Tick    int64                    `json:"tick"`
+	Players []UserGameLocation       `json:"players"`
+	Scores  map[string]int           `json:"scores"`
+	Flags   map[string]game.FlagView `json:"flags"`
+}
+
+type MatchEndedPayload struct {
+	Mode       string                 `json:"mode"`
+	Winner     string                 `json:"winner"`
+	Scores     map[string]int         `json:"scores"`
+	Scoreboard []game.ScoreboardEntry `json:"scoreboard"`
+}
+
+type LeaderboardPayload struct {
+	Stat   string              `json:"stat"`
+	Window string              `json:"window"`
+	Top    []leaderboard.Entry `json:"top"`
+	Around []leaderboard.Entry `json:"around"`
+}
+
+type PhasePayload struct {
+	Phase  string    `json:"phase"`
+	EndsAt time.Time `json:"endsAt"`
 }
@@ -0,0 +1,15 @@
+package store
+
+import (
+	"shooter/game"
+)
+
+// GameStore keeps the states of running games by game id
+type GameStore interface {
+	Load(gameId string) (*game.GameState, error)
+	// Update applies the change to the stored state atomically and returns the saved state
+	Update(gameId string, update func(gameState *game.GameState)) (*game.GameState, error)
+	Delete(gameId string) error
+	// List returns every stored state by game id
+	List() (map[string]*game.GameState, error)
+}
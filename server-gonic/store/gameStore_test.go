@@ -0,0 +1,207 @@
+package store
+
+import (
+	"fmt"
+	"shooter/game"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// newTestRedis returns a client of an embedded Redis living as long as the test
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	server := miniredis.RunT(t)
+	db := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// testConcurrentUpdates lets many goroutines change the same game at once,
+// every one of the changes has to end up in the saved state
+func testConcurrentUpdates(t *testing.T, games GameStore) {
+	const writers = 4
+	const updatesPerWriter = 25
+	gameId := "test-" + uuid.NewString()
+	t.Cleanup(func() { games.Delete(gameId) })
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*updatesPerWriter)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < updatesPerWriter; j++ {
+				_, err := games.Update(gameId, func(gameState *game.GameState) {
+					gameState.Scores["updates"]++
+				})
+				if err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	gameState, err := games.Load(gameId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gameState.Scores["updates"] != writers*updatesPerWriter {
+		t.Errorf("updates = %d, want %d", gameState.Scores["updates"], writers*updatesPerWriter)
+	}
+}
+
+// testConcurrentMoves lets the players in the corners of the default map walk
+// into each other at the same time. Every player must end up where its last
+// move put it, and no two players may ever share a cell.
+func testConcurrentMoves(t *testing.T, games GameStore) {
+	const moves = 12
+	gameId := "test-" + uuid.NewString()
+	t.Cleanup(func() { games.Delete(gameId) })
+
+	players := []string{"a", "b", "c", "d"}
+	start, err := games.Update(gameId, func(gameState *game.GameState) {
+		for _, playerId := range players {
+			if err := gameState.AddPlayer(playerId, nil, ""); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	expected := map[string]game.Position{}
+	errs := make(chan error, len(players)*moves*2)
+	for _, playerId := range players {
+		// the walk leads through the middle of the map and back
+		from := *start.Locations[playerId]
+		step := game.Position{X: towardsMiddle(from.X), Y: towardsMiddle(from.Y)}
+
+		wg.Add(1)
+		go func(playerId string) {
+			defer wg.Done()
+			var at game.Position
+			for j := 0; j < 2*moves; j++ {
+				if j == moves {
+					step = game.Position{X: -step.X, Y: -step.Y}
+				}
+				saved, err := games.Update(gameId, func(gameState *game.GameState) {
+					moved, _ := gameState.MovePlayer(playerId, step)
+					at = *moved
+				})
+				if err != nil {
+					errs <- err
+					continue
+				}
+				if err := sharedCell(saved); err != nil {
+					errs <- err
+				}
+			}
+			mutex.Lock()
+			expected[playerId] = at
+			mutex.Unlock()
+		}(playerId)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	gameState, err := games.Load(gameId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for playerId, at := range expected {
+		if *gameState.Locations[playerId] != at {
+			t.Errorf("%s is at %v, its last move put it at %v", playerId, *gameState.Locations[playerId], at)
+		}
+	}
+	if err := sharedCell(gameState); err != nil {
+		t.Error(err)
+	}
+}
+
+func towardsMiddle(coordinate int) int {
+	if coordinate < 5 {
+		return 1
+	}
+	return -1
+}
+
+func sharedCell(gameState *game.GameState) error {
+	occupied := map[game.Position]string{}
+	for playerId, at := range gameState.Locations {
+		if other, ok := occupied[*at]; ok {
+			return fmt.Errorf("%s and %s share the cell %v", other, playerId, *at)
+		}
+		occupied[*at] = playerId
+	}
+	return nil
+}
+
+func TestMemoryGameStoreConcurrentUpdates(t *testing.T) {
+	testConcurrentUpdates(t, NewMemoryGameStore())
+}
+
+func TestRedisGameStoreConcurrentUpdates(t *testing.T) {
+	testConcurrentUpdates(t, NewRedisGameStore(newTestRedis(t)))
+}
+
+func TestMemoryGameStoreConcurrentMoves(t *testing.T) {
+	testConcurrentMoves(t, NewMemoryGameStore())
+}
+
+func TestRedisGameStoreConcurrentMoves(t *testing.T) {
+	testConcurrentMoves(t, NewRedisGameStore(newTestRedis(t)))
+}
+
+func testList(t *testing.T, games GameStore) {
+	gameIds := []string{"test-" + uuid.NewString(), "test-" + uuid.NewString()}
+	for i, gameId := range gameIds {
+		score := i + 1
+		if _, err := games.Update(gameId, func(gameState *game.GameState) {
+			gameState.Scores["listed"] = score
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	games.Delete(gameIds[1])
+	t.Cleanup(func() { games.Delete(gameIds[0]) })
+
+	listed, err := games.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[gameIds[0]] == nil {
+		t.Fatalf("listed = %v, want only %s", listed, gameIds[0])
+	}
+	if score := listed[gameIds[0]].Scores["listed"]; score != 1 {
+		t.Errorf("score = %d, want 1", score)
+	}
+}
+
+func TestMemoryGameStoreList(t *testing.T) {
+	testList(t, NewMemoryGameStore())
+}
+
+func TestRedisGameStoreList(t *testing.T) {
+	db := newTestRedis(t)
+	// the members of a room are stored next to its game and mustn't be listed
+	rooms := NewRedisRoomStore(db)
+	roomId := uuid.NewString()
+	rooms.Save(Room{ID: roomId})
+	rooms.AddMember(roomId, "member")
+	testList(t, NewRedisGameStore(db))
+}
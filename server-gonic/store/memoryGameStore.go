@@ -0,0 +1,77 @@
+package store
+
+import (
+	"shooter/game"
+	"sync"
+)
+
+// MemoryGameStore keeps game states in the memory of the process. States are
+// stored serialized, the same way as in Redis, so callers never share them.
+type MemoryGameStore struct {
+	mutex sync.Mutex
+	games map[string][]byte
+}
+
+func NewMemoryGameStore() *MemoryGameStore {
+	return &MemoryGameStore{games: map[string][]byte{}}
+}
+
+func (store *MemoryGameStore) Load(gameId string) (*game.GameState, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.load(gameId)
+}
+
+func (store *MemoryGameStore) Update(gameId string, update func(gameState *game.GameState)) (*game.GameState, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	gameState, err := store.load(gameId)
+	if err != nil {
+		return nil, err
+	}
+
+	update(gameState)
+
+	saved, err := gameState.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	store.games[gameId] = saved
+	return gameState, nil
+}
+
+func (store *MemoryGameStore) Delete(gameId string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.games, gameId)
+	return nil
+}
+
+func (store *MemoryGameStore) List() (map[string]*game.GameState, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	games := map[string]*game.GameState{}
+	for gameId := range store.games {
+		gameState, err := store.load(gameId)
+		if err != nil {
+			return nil, err
+		}
+		games[gameId] = gameState
+	}
+	return games, nil
+}
+
+func (store *MemoryGameStore) load(gameId string) (*game.GameState, error) {
+	gameState := game.NewGame()
+	saved, ok := store.games[gameId]
+	if !ok {
+		return gameState, nil
+	}
+	if err := gameState.UnmarshalBinary(saved); err != nil {
+		return nil, err
+	}
+	return gameState, nil
+}
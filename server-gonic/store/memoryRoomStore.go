@@ -0,0 +1,135 @@
+package store
+
+import (
+	"sync"
+
+	"golang.org/x/exp/maps"
+)
+
+// MemoryRoomStore keeps the rooms in the memory of the process
+type MemoryRoomStore struct {
+	mutex   sync.Mutex
+	rooms   map[string]Room
+	members map[string]map[string]bool
+}
+
+func NewMemoryRoomStore() *MemoryRoomStore {
+	return &MemoryRoomStore{rooms: map[string]Room{}, members: map[string]map[string]bool{}}
+}
+
+func (store *MemoryRoomStore) Save(room Room) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	room.Players = 0
+	store.rooms[room.ID] = room
+	return nil
+}
+
+func (store *MemoryRoomStore) Get(roomId string) (Room, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.get(roomId)
+}
+
+func (store *MemoryRoomStore) get(roomId string) (Room, error) {
+	room, ok := store.rooms[roomId]
+	if !ok {
+		return Room{}, ErrRoomNotFound
+	}
+	room.Players = int64(len(store.members[roomId]))
+	return room, nil
+}
+
+func (store *MemoryRoomStore) List() ([]Room, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	rooms := []Room{}
+	for roomId := range store.rooms {
+		room, _ := store.get(roomId)
+		rooms = append(rooms, room)
+	}
+	return rooms, nil
+}
+
+func (store *MemoryRoomStore) AddMember(roomId string, clientId string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.rooms[roomId]; !ok {
+		return ErrRoomNotFound
+	}
+	if store.members[roomId] == nil {
+		store.members[roomId] = map[string]bool{}
+	}
+	store.members[roomId][clientId] = true
+	return nil
+}
+
+func (store *MemoryRoomStore) RemoveMember(roomId string, clientId string) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.members[roomId], clientId)
+	return int64(len(store.members[roomId])), nil
+}
+
+func (store *MemoryRoomStore) Members(roomId string) ([]string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return maps.Keys(store.members[roomId]), nil
+}
+
+func (store *MemoryRoomStore) Delete(roomId string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.rooms, roomId)
+	delete(store.members, roomId)
+	return nil
+}
+
+func (store *MemoryRoomStore) DeleteIfEmpty(roomId string) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if len(store.members[roomId]) > 0 {
+		return false, nil
+	}
+	delete(store.rooms, roomId)
+	delete(store.members, roomId)
+	return true, nil
+}
+
+// MemoryQueueStore keeps the matchmaking queue in the memory of the process
+type MemoryQueueStore struct {
+	mutex   sync.Mutex
+	tickets map[int]QueueTicket
+}
+
+func NewMemoryQueueStore() *MemoryQueueStore {
+	return &MemoryQueueStore{tickets: map[int]QueueTicket{}}
+}
+
+func (store *MemoryQueueStore) Save(ticket QueueTicket) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.tickets[ticket.UserID] = ticket
+	return nil
+}
+
+func (store *MemoryQueueStore) Delete(userId int) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.tickets, userId)
+	return nil
+}
+
+func (store *MemoryQueueStore) Tickets() ([]QueueTicket, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return maps.Values(store.tickets), nil
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"shooter/game"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// maxUpdateRetries limits how many times a conflicting update is replayed
+const maxUpdateRetries = 50
+
+// maxRetryPause is the longest random pause before a conflicting update is
+// replayed, so that writers replaying at once don't collide again and again
+const maxRetryPause = 5 * time.Millisecond
+
+var ErrUpdateConflict = errors.New("stored state was changed concurrently too many times")
+
+// RedisGameStore keeps game states in Redis. Updates are optimistic
+// transactions: the key is watched while the state is changed, and the
+// change is replayed on a fresh copy if somebody else saved it meanwhile.
+type RedisGameStore struct {
+	db *redis.Client
+}
+
+func NewRedisGameStore(db *redis.Client) *RedisGameStore {
+	return &RedisGameStore{db: db}
+}
+
+const gameKeySuffix = ":game"
+
+func gameKey(gameId string) string {
+	return roomKey(gameId) + gameKeySuffix
+}
+
+func (store *RedisGameStore) Load(gameId string) (*game.GameState, error) {
+	return load(context.Background(), store.db, gameKey(gameId))
+}
+
+// Update applies the change to the stored game state atomically and returns the
+// saved state. The change may run several times, so it must not have side effects
+// other than on the state and on variables it resets itself.
+func (store *RedisGameStore) Update(gameId string, update func(gameState *game.GameState)) (*game.GameState, error) {
+	ctx := context.Background()
+	key := gameKey(gameId)
+
+	var updated *game.GameState
+	transaction := func(tx *redis.Tx) error {
+		gameState, err := load(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		update(gameState)
+
+		saved, err := gameState.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, saved, 0)
+			return nil
+		})
+		updated = gameState
+		return err
+	}
+
+	if err := watch(ctx, store.db, transaction, key); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// watch runs the optimistic transaction on the keys, it's replayed after a
+// random pause whenever one of them changed before it was committed
+func watch(ctx context.Context, db *redis.Client, transaction func(tx *redis.Tx) error, keys ...string) error {
+	for i := 0; i < maxUpdateRetries; i++ {
+		err := db.Watch(ctx, transaction, keys...)
+		if err == redis.TxFailedErr {
+			time.Sleep(time.Duration(rand.Int63n(int64(maxRetryPause))))
+			continue
+		}
+		return err
+	}
+	return ErrUpdateConflict
+}
+
+func (store *RedisGameStore) Delete(gameId string) error {
+	return store.db.Del(context.Background(), gameKey(gameId)).Err()
+}
+
+func (store *RedisGameStore) List() (map[string]*game.GameState, error) {
+	ctx := context.Background()
+	games := map[string]*game.GameState{}
+
+	iterator := store.db.Scan(ctx, 0, gameKey("*"), 0).Iterator()
+	for iterator.Next(ctx) {
+		key := iterator.Val()
+		gameState, err := load(ctx, store.db, key)
+		if err != nil {
+			return nil, err
+		}
+		gameId := strings.TrimSuffix(strings.TrimPrefix(key, roomKey("")), gameKeySuffix)
+		games[gameId] = gameState
+	}
+	return games, iterator.Err()
+}
+
+func load(ctx context.Context, db redis.Cmdable, key string) (*game.GameState, error) {
+	gameState := game.NewGame()
+	saved, err := db.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return gameState, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := gameState.UnmarshalBinary([]byte(saved)); err != nil {
+		return nil, err
+	}
+	return gameState, nil
+}
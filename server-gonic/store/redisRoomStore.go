@@ -0,0 +1,197 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const redisRoomsKey = "rooms"
+const redisQueueKey = "matchmaking:queue"
+
+func roomKey(roomId string) string {
+	return "room:" + roomId
+}
+
+func roomMembersKey(roomId string) string {
+	return roomKey(roomId) + ":members"
+}
+
+// RedisRoomStore keeps every room in a hash of its settings and a set of its
+// members, the ids of all rooms are kept in a set of their own
+type RedisRoomStore struct {
+	db *redis.Client
+}
+
+func NewRedisRoomStore(db *redis.Client) *RedisRoomStore {
+	return &RedisRoomStore{db: db}
+}
+
+func (store *RedisRoomStore) Save(room Room) error {
+	ctx := context.Background()
+	_, err := store.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, roomKey(room.ID),
+			"name", room.Name,
+			"mode", room.Mode,
+			"map", room.Map,
+			"friendlyFire", strconv.FormatBool(room.FriendlyFire),
+			"botDifficulty", room.BotDifficulty,
+		)
+		pipe.SAdd(ctx, redisRoomsKey, room.ID)
+		return nil
+	})
+	return err
+}
+
+func (store *RedisRoomStore) Get(roomId string) (Room, error) {
+	ctx := context.Background()
+	exists, err := store.db.SIsMember(ctx, redisRoomsKey, roomId).Result()
+	if err != nil {
+		return Room{}, err
+	}
+	if !exists {
+		return Room{}, ErrRoomNotFound
+	}
+
+	fields, err := store.db.HGetAll(ctx, roomKey(roomId)).Result()
+	if err != nil {
+		return Room{}, err
+	}
+	players, err := store.db.SCard(ctx, roomMembersKey(roomId)).Result()
+	if err != nil {
+		return Room{}, err
+	}
+	friendlyFire, _ := strconv.ParseBool(fields["friendlyFire"])
+	return Room{
+		ID:            roomId,
+		Name:          fields["name"],
+		Mode:          fields["mode"],
+		Map:           fields["map"],
+		FriendlyFire:  friendlyFire,
+		BotDifficulty: fields["botDifficulty"],
+		Players:       players,
+	}, nil
+}
+
+func (store *RedisRoomStore) List() ([]Room, error) {
+	roomIds, err := store.db.SMembers(context.Background(), redisRoomsKey).Result()
+	if err != nil {
+		return []Room{}, err
+	}
+
+	rooms := []Room{}
+	for _, roomId := range roomIds {
+		room, err := store.Get(roomId)
+		if err != nil {
+			continue
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, nil
+}
+
+// AddMember watches the settings of the room, which are gone once it's deleted
+func (store *RedisRoomStore) AddMember(roomId string, clientId string) error {
+	ctx := context.Background()
+	return watch(ctx, store.db, func(tx *redis.Tx) error {
+		exists, err := tx.Exists(ctx, roomKey(roomId)).Result()
+		if err != nil {
+			return err
+		}
+		if exists == 0 {
+			return ErrRoomNotFound
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.SAdd(ctx, roomMembersKey(roomId), clientId)
+			return nil
+		})
+		return err
+	}, roomKey(roomId))
+}
+
+func (store *RedisRoomStore) RemoveMember(roomId string, clientId string) (int64, error) {
+	ctx := context.Background()
+	var members *redis.IntCmd
+	_, err := store.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SRem(ctx, roomMembersKey(roomId), clientId)
+		members = pipe.SCard(ctx, roomMembersKey(roomId))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return members.Val(), nil
+}
+
+func (store *RedisRoomStore) Members(roomId string) ([]string, error) {
+	return store.db.SMembers(context.Background(), roomMembersKey(roomId)).Result()
+}
+
+func (store *RedisRoomStore) Delete(roomId string) error {
+	ctx := context.Background()
+	_, err := store.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SRem(ctx, redisRoomsKey, roomId)
+		pipe.Del(ctx, roomKey(roomId), roomMembersKey(roomId))
+		return nil
+	})
+	return err
+}
+
+// DeleteIfEmpty watches the members of the room, a member joining meanwhile
+// makes it look again
+func (store *RedisRoomStore) DeleteIfEmpty(roomId string) (bool, error) {
+	ctx := context.Background()
+	deleted := false
+	err := watch(ctx, store.db, func(tx *redis.Tx) error {
+		deleted = false
+		members, err := tx.SCard(ctx, roomMembersKey(roomId)).Result()
+		if err != nil || members > 0 {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.SRem(ctx, redisRoomsKey, roomId)
+			pipe.Del(ctx, roomKey(roomId), roomMembersKey(roomId))
+			return nil
+		})
+		deleted = err == nil
+		return err
+	}, roomMembersKey(roomId))
+	return deleted, err
+}
+
+// RedisQueueStore keeps the tickets of the queue in a hash by user id
+type RedisQueueStore struct {
+	db *redis.Client
+}
+
+func NewRedisQueueStore(db *redis.Client) *RedisQueueStore {
+	return &RedisQueueStore{db: db}
+}
+
+func (store *RedisQueueStore) Save(ticket QueueTicket) error {
+	saved, _ := json.Marshal(ticket)
+	return store.db.HSet(context.Background(), redisQueueKey, strconv.Itoa(ticket.UserID), saved).Err()
+}
+
+func (store *RedisQueueStore) Delete(userId int) error {
+	return store.db.HDel(context.Background(), redisQueueKey, strconv.Itoa(userId)).Err()
+}
+
+func (store *RedisQueueStore) Tickets() ([]QueueTicket, error) {
+	saved, err := store.db.HVals(context.Background(), redisQueueKey).Result()
+	if err != nil {
+		return []QueueTicket{}, err
+	}
+
+	tickets := []QueueTicket{}
+	for _, data := range saved {
+		var ticket QueueTicket
+		if err := json.Unmarshal([]byte(data), &ticket); err != nil {
+			continue
+		}
+		tickets = append(tickets, ticket)
+	}
+	return tickets, nil
+}
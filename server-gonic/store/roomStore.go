@@ -0,0 +1,55 @@
+package store
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrRoomNotFound = errors.New("room not found")
+
+// Room is a single match running on the server
+type Room struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Mode          string `json:"mode"`
+	Map           string `json:"map"`
+	FriendlyFire  bool   `json:"friendlyFire"`
+	BotDifficulty string `json:"botDifficulty"`
+	Players       int64  `json:"players"`
+}
+
+// RoomStore keeps the settings of the rooms and the clients playing in them
+type RoomStore interface {
+	// Save creates the room or overwrites its settings, members are kept
+	Save(room Room) error
+	// Get returns ErrRoomNotFound for rooms that were never saved or are deleted
+	Get(roomId string) (Room, error)
+	List() ([]Room, error)
+	// AddMember returns ErrRoomNotFound unless the room exists at that moment
+	AddMember(roomId string, clientId string) error
+	// RemoveMember returns the number of members left in the room
+	RemoveMember(roomId string, clientId string) (int64, error)
+	Members(roomId string) ([]string, error)
+	Delete(roomId string) error
+	// DeleteIfEmpty deletes the room in the same step as it finds it without
+	// members, so nobody can join in between. It reports whether it deleted it.
+	DeleteIfEmpty(roomId string) (bool, error)
+}
+
+// QueueTicket is a user waiting for a match. Tickets are kept by user rather
+// than by connection, so they outlive reconnects and restarts of the server.
+type QueueTicket struct {
+	UserID   int       `json:"userId"`
+	Mode     string    `json:"mode"`
+	Region   string    `json:"region"`
+	Rating   float64   `json:"rating"`
+	QueuedAt time.Time `json:"queuedAt"`
+	Widened  bool      `json:"widened"`
+}
+
+// QueueStore keeps the matchmaking queue, a user has at most one ticket
+type QueueStore interface {
+	Save(ticket QueueTicket) error
+	Delete(userId int) error
+	Tickets() ([]QueueTicket, error)
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func testDeleteIfEmpty(t *testing.T, rooms RoomStore) {
+	t.Run("a room with members is kept", func(t *testing.T) {
+		roomId := uuid.NewString()
+		rooms.Save(Room{ID: roomId})
+		if err := rooms.AddMember(roomId, "member"); err != nil {
+			t.Fatal(err)
+		}
+		deleted, err := rooms.DeleteIfEmpty(roomId)
+		if err != nil || deleted {
+			t.Errorf("deleted = %v, %v, want the room kept", deleted, err)
+		}
+		if _, err := rooms.Get(roomId); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("nobody joins a deleted room", func(t *testing.T) {
+		roomId := uuid.NewString()
+		rooms.Save(Room{ID: roomId})
+		deleted, err := rooms.DeleteIfEmpty(roomId)
+		if err != nil || !deleted {
+			t.Fatalf("deleted = %v, %v, want the room deleted", deleted, err)
+		}
+		if err := rooms.AddMember(roomId, "late"); !errors.Is(err, ErrRoomNotFound) {
+			t.Errorf("joining = %v, want %v", err, ErrRoomNotFound)
+		}
+		if members, _ := rooms.Members(roomId); len(members) != 0 {
+			t.Errorf("members = %v, want none", members)
+		}
+	})
+
+	// whatever happens first, a member never ends up in a deleted room
+	t.Run("joining while the room is deleted", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			roomId := uuid.NewString()
+			rooms.Save(Room{ID: roomId})
+
+			var wg sync.WaitGroup
+			var joinErr error
+			var deleted bool
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				joinErr = rooms.AddMember(roomId, "member")
+			}()
+			go func() {
+				defer wg.Done()
+				deleted, _ = rooms.DeleteIfEmpty(roomId)
+			}()
+			wg.Wait()
+
+			_, getErr := rooms.Get(roomId)
+			switch {
+			case joinErr == nil && (deleted || getErr != nil):
+				t.Fatalf("the member joined a deleted room")
+			case joinErr != nil && !deleted:
+				t.Fatalf("joining failed with %v although the room was kept", joinErr)
+			}
+		}
+	})
+}
+
+func TestMemoryRoomStoreDeleteIfEmpty(t *testing.T) {
+	testDeleteIfEmpty(t, NewMemoryRoomStore())
+}
+
+func TestRedisRoomStoreDeleteIfEmpty(t *testing.T) {
+	testDeleteIfEmpty(t, NewRedisRoomStore(newTestRedis(t)))
+}
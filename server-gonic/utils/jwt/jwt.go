@@ -1,6 +1,7 @@
 package jwt_token
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -9,26 +10,38 @@ import (
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
+// TokenProtocol is the websocket subprotocol carrying the token, browsers send
+// it as the Sec-WebSocket-Protocol header "access_token, <token>"
+const TokenProtocol = "access_token"
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// AllowQueryToken lets the token be passed in the "token" query parameter,
+// which ends up in access logs
+var AllowQueryToken = true
+
+// AccessTokenLifespan is kept short, a client stays logged in by
+// trading its refresh token for a new access token
+var AccessTokenLifespan = 15 * time.Minute
+
 type TokenData struct {
 	UserId   uint
 	UserName string
+	// Family is shared by all tokens issued since the login, so they can be revoked together
+	Family string
 }
 
-func GenerateToken(user_id uint, user_name string) (string, error) {
-
-	token_lifespan, err := strconv.Atoi(os.Getenv("TOKEN_HOUR_LIFESPAN"))
-
-	if err != nil {
-		return "", err
-	}
+func GenerateToken(user_id uint, user_name string, family string) (string, error) {
 
 	claims := jwt.MapClaims{}
 	claims["authorized"] = true
 	claims["userId"] = user_id
 	claims["userName"] = user_name
-	claims["exp"] = time.Now().Add(time.Hour * time.Duration(token_lifespan)).Unix()
+	claims["family"] = family
+	claims["exp"] = time.Now().Add(AccessTokenLifespan).Unix()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	return token.SignedString([]byte(os.Getenv("API_SECRET")))
@@ -51,7 +64,7 @@ func TokenValid(c *gin.Context) error {
 
 func ExtractToken(c *gin.Context) string {
 	token := c.Query("token")
-	if token != "" {
+	if token != "" && AllowQueryToken {
 		return token
 	}
 	bearerToken := c.Request.Header.Get("Authorization")
@@ -60,6 +73,13 @@ func ExtractToken(c *gin.Context) string {
 	if len(tokenStrings) == 2 {
 		return tokenStrings[1]
 	}
+
+	protocols := websocket.Subprotocols(c.Request)
+	for i, protocol := range protocols {
+		if protocol == TokenProtocol && i+1 < len(protocols) {
+			return protocols[i+1]
+		}
+	}
 	return ""
 }
 
@@ -78,14 +98,23 @@ func ExtractTokenData(c *gin.Context) (TokenData, error) {
 		return tokenData, err
 	}
 	claims, ok := token.Claims.(jwt.MapClaims)
-	if ok && token.Valid {
-		uid, err := strconv.ParseUint(fmt.Sprintf("%.0f", claims["userId"]), 10, 32)
-		if err != nil {
-			return tokenData, err
-		}
-		tokenData.UserId = uint(uid)
-		tokenData.UserName = claims["userName"].(string)
-		return tokenData, nil
+	if !ok || !token.Valid {
+		return tokenData, ErrInvalidToken
+	}
+	uid, err := strconv.ParseUint(fmt.Sprintf("%.0f", claims["userId"]), 10, 32)
+	if err != nil {
+		return tokenData, err
+	}
+	userName, ok := claims["userName"].(string)
+	if !ok {
+		return tokenData, ErrInvalidToken
+	}
+	family, ok := claims["family"].(string)
+	if !ok {
+		return tokenData, ErrInvalidToken
 	}
+	tokenData.UserId = uint(uid)
+	tokenData.UserName = userName
+	tokenData.Family = family
 	return tokenData, nil
 }
@@ -0,0 +1,24 @@
+package revoked_tokens
+
+import (
+	"context"
+	jwt_token "shooter/utils/jwt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func familyKey(family string) string {
+	return "revokedFamily:" + family
+}
+
+// RevokeFamily refuses the access tokens of the family from now on. The
+// entry is kept as long as an access token lives, after that the tokens
+// have expired anyway.
+func RevokeFamily(db *redis.Client, family string) error {
+	return db.Set(context.Background(), familyKey(family), 1, jwt_token.AccessTokenLifespan).Err()
+}
+
+func IsRevoked(db *redis.Client, tokenData jwt_token.TokenData) (bool, error) {
+	revoked, err := db.Exists(context.Background(), familyKey(tokenData.Family)).Result()
+	return revoked > 0, err
+}
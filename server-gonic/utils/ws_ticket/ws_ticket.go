@@ -0,0 +1,53 @@
+package ws_ticket
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	jwt_token "shooter/utils/jwt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// TicketLifespan is how long a ticket can be used to open a websocket
+var TicketLifespan = 30 * time.Second
+
+var ErrInvalidTicket = errors.New("invalid or used ticket")
+
+func ticketKey(ticket string) string {
+	return "wsTicket:" + ticket
+}
+
+// Issue stores a random single-use ticket standing for the user
+func Issue(db *redis.Client, tokenData jwt_token.TokenData) (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	ticket := hex.EncodeToString(random)
+
+	saved, _ := json.Marshal(tokenData)
+	err := db.Set(context.Background(), ticketKey(ticket), saved, TicketLifespan).Err()
+	if err != nil {
+		return "", err
+	}
+	return ticket, nil
+}
+
+// Consume returns the user the ticket stands for and deletes it in the same
+// step, so a ticket never opens more than one connection
+func Consume(db *redis.Client, ticket string) (jwt_token.TokenData, error) {
+	tokenData := jwt_token.TokenData{}
+	saved, err := db.GetDel(context.Background(), ticketKey(ticket)).Result()
+	if err == redis.Nil {
+		return tokenData, ErrInvalidTicket
+	}
+	if err != nil {
+		return tokenData, err
+	}
+	err = json.Unmarshal([]byte(saved), &tokenData)
+	return tokenData, err
+}